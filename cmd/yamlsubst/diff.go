@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// edit is one line of a line-based diff
+type edit struct {
+	op   byte // ' ' unchanged, '-' removed, '+' added
+	line string
+}
+
+// writeUnifiedDiff writes a unified diff turning oldText into newText.
+// Nothing is written when both are equal.
+func writeUnifiedDiff(w io.Writer, oldName, newName, oldText, newText string) error {
+	if oldText == newText {
+		return nil
+	}
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(edits) {
+		writeHunk(&sb, edits, h)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// splitLines splits text into lines that keep their trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm. Lines
+// common to the start and end are matched directly; if the rest needs more
+// than maxDiffEdits edits, it is shown as removed and re-added as a whole.
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{op: ' ', line: line})
+	}
+	oldMiddle, newMiddle := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	middle, ok := myers(oldMiddle, newMiddle, maxDiffEdits)
+	if !ok {
+		middle = replaceLines(oldMiddle, newMiddle)
+	}
+	edits = append(edits, middle...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{op: ' ', line: line})
+	}
+	return edits
+}
+
+// maxDiffEdits bounds the edit distance myers searches. Its trace grows with
+// the square of the distance, so completely rewritten files are not diffed
+// line by line.
+const maxDiffEdits = 1000
+
+// myers computes a shortest edit script of at most maxD edits; ok is false
+// if a and b differ by more
+func myers(a, b []string, maxD int) (edits []edit, ok bool) {
+	n, m := len(a), len(b)
+	maxD = min(maxD, n+m)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// Backtracking from step d only reads diagonals -d-1..d+1
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack walks the Myers trace from the end to recover the edit script.
+// trace[d] holds the diagonals -d-1..d+1 before step d.
+func backtrack(a, b []string, trace [][]int) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v, offset := trace[d], d+1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: '+', line: b[y-1]})
+			} else {
+				edits = append(edits, edit{op: '-', line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// replaceLines is the edit script removing all of a and adding all of b
+func replaceLines(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{op: '-', line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{op: '+', line: line})
+	}
+	return edits
+}
+
+// hunk is a half-open range of edits printed together
+type hunk struct {
+	start, end int
+}
+
+// hunks groups changed edits with their context, merging overlapping ranges
+func hunks(edits []edit) []hunk {
+	var result []hunk
+	for i := 0; i < len(edits); i++ {
+		if edits[i].op == ' ' {
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := min(i+1+diffContext, len(edits))
+		if n := len(result); n > 0 && start <= result[n-1].end {
+			result[n-1].end = end
+		} else {
+			result = append(result, hunk{start: start, end: end})
+		}
+	}
+	return result
+}
+
+// writeHunk writes a single hunk with its @@ header
+func writeHunk(sb *strings.Builder, edits []edit, h hunk) {
+	// Line numbers before the hunk
+	oldLine, newLine := 1, 1
+	for _, e := range edits[:h.start] {
+		if e.op != '+' {
+			oldLine++
+		}
+		if e.op != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, e := range edits[h.start:h.end] {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	// An empty range starts at the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, e := range edits[h.start:h.end] {
+		sb.WriteByte(e.op)
+		sb.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
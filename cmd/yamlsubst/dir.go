@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// dirEntry is a regular file found below --input-dir
+type dirEntry struct {
+	rel  string // slash-separated path relative to --input-dir
+	mode fs.FileMode
+}
+
+// runDir renders the --input-dir tree into --output-dir, mirroring its structure.
+// Matching files are rendered, all other files are copied verbatim.
+func runDir(data interface{}) error {
+	if err := validateGlobs(includeGlobs); err != nil {
+		return err
+	}
+	if err := validateGlobs(excludeGlobs); err != nil {
+		return err
+	}
+
+	entries, err := collectDir(inputDir, outputDir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		src := filepath.Join(inputDir, filepath.FromSlash(e.rel))
+		content, err := os.ReadFile(src) // #nosec G304 -- CLI tool reads user-specified files
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		rel := e.rel
+		if isTemplate(rel) {
+			content = []byte(substitute(string(content), data))
+			rel = path.Join(path.Dir(rel), stripOutputSuffix(path.Base(rel)))
+		}
+
+		if err := writeOutput(filepath.Join(outputDir, filepath.FromSlash(rel)), content, e.mode.Perm()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stripOutputSuffix removes --strip-suffix from a rendered file name,
+// unless that would leave the name empty
+func stripOutputSuffix(name string) string {
+	if stripSuffix != "" && len(name) > len(stripSuffix) {
+		return strings.TrimSuffix(name, stripSuffix)
+	}
+	return name
+}
+
+// collectDir lists the regular files below root in lexical order.
+// The output directory is skipped when it lies inside the input tree.
+func collectDir(root, skip string) ([]dirEntry, error) {
+	skipAbs, err := filepath.Abs(skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	var entries []dirEntry
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, err := filepath.Abs(p); err == nil && abs == skipAbs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			fmt.Fprintf(os.Stderr, "skipping %s: not a regular file\n", p)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, dirEntry{rel: filepath.ToSlash(rel), mode: info.Mode()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input directory: %w", err)
+	}
+	return entries, nil
+}
+
+// isTemplate reports whether the file at rel is rendered: it must match an
+// --include glob (if any are given) and no --exclude glob
+func isTemplate(rel string) bool {
+	if len(includeGlobs) > 0 && !matchAny(includeGlobs, rel) {
+		return false
+	}
+	return !matchAny(excludeGlobs, rel)
+}
+
+// matchAny matches rel against globs. Globs containing a slash match the whole
+// relative path, all others match the base name.
+func matchAny(globs []string, rel string) bool {
+	base := path.Base(rel)
+	for _, g := range globs {
+		target := base
+		if strings.Contains(g, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(g, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGlobs rejects malformed glob patterns up front
+func validateGlobs(globs []string) error {
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+	}
+	return nil
+}
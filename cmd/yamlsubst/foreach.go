@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/huberp/yamlsubst/pkg/substitutor"
+)
+
+// foreachLoop is the name under which the loop variables index and key are bound
+const foreachLoop = "loop"
+
+// foreachBinding is one element of the --foreach collection
+type foreachBinding struct {
+	index int
+	key   string // empty when iterating a list
+	item  interface{}
+}
+
+// runForeach renders the template once per element of the --foreach collection
+func runForeach(data interface{}, template string) error {
+	if !isIdentifier(foreachAs) {
+		return fmt.Errorf("invalid --foreach-as name: %q", foreachAs)
+	}
+	if foreachAs == foreachLoop {
+		return fmt.Errorf("--foreach-as name %q is reserved for the loop variables", foreachAs)
+	}
+	if root, ok := data.(map[string]interface{}); ok {
+		if _, ok := root[foreachAs]; ok {
+			return fmt.Errorf("--foreach would hide the top-level key %q; choose another name with --foreach-as", foreachAs)
+		}
+		if _, ok := root[foreachLoop]; ok {
+			return fmt.Errorf("--foreach would hide the top-level key %q with its loop variables", foreachLoop)
+		}
+	}
+
+	bindings, err := foreachBindings(data, foreachPath)
+	if err != nil {
+		return err
+	}
+
+	var combined strings.Builder
+	written := make(map[string]int, len(bindings))
+	for _, b := range bindings {
+		bound := bindForeach(data, b)
+		result := substitute(template, bound)
+
+		if foreachOutput == "" {
+			combined.WriteString(result)
+			continue
+		}
+
+		path, err := renderOutputPath(foreachOutput, bound)
+		if err != nil {
+			return err
+		}
+		if prev, ok := written[path]; ok {
+			return fmt.Errorf("foreach output path %q rendered for both index %d and %d", path, prev, b.index)
+		}
+		written[path] = b.index
+
+		if err := writeOutput(path, []byte(result), existingMode(path, 0o644)); err != nil {
+			return err
+		}
+	}
+
+	if foreachOutput == "" {
+		return writeResult(combined.String())
+	}
+	return nil
+}
+
+// foreachBindings resolves the collection at path into bindings.
+// Maps are iterated in sorted key order so output is deterministic.
+func foreachBindings(data interface{}, path string) ([]foreachBinding, error) {
+	switch v := substitutor.Lookup(data, path).(type) {
+	case []interface{}:
+		bindings := make([]foreachBinding, len(v))
+		for i, item := range v {
+			bindings[i] = foreachBinding{index: i, item: item}
+		}
+		return bindings, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		bindings := make([]foreachBinding, len(keys))
+		for i, k := range keys {
+			bindings[i] = foreachBinding{index: i, key: k, item: v[k]}
+		}
+		return bindings, nil
+	case nil:
+		return nil, fmt.Errorf("foreach path not found: %s", path)
+	default:
+		return nil, fmt.Errorf("foreach path %s is neither a list nor a map", path)
+	}
+}
+
+// bindForeach returns a shallow copy of the root data with the current element
+// bound under foreachAs and its position under loop.index (and key for maps
+// under loop.key)
+func bindForeach(data interface{}, b foreachBinding) interface{} {
+	root, _ := data.(map[string]interface{})
+	bound := make(map[string]interface{}, len(root)+2)
+	for k, v := range root {
+		bound[k] = v
+	}
+
+	loop := map[string]interface{}{"index": b.index}
+	if b.key != "" {
+		loop["key"] = b.key
+	}
+	bound[foreachAs] = b.item
+	bound[foreachLoop] = loop
+	return bound
+}
+
+// renderOutputPath substitutes placeholders in an output path template.
+// Unresolved placeholders are an error rather than a literal file name.
+func renderOutputPath(pathTemplate string, data interface{}) (string, error) {
+	path := substitutor.SubstituteData(pathTemplate, data)
+	if strings.Contains(path, "${") {
+		return "", fmt.Errorf("unresolved placeholder in output path: %s", path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// isIdentifier reports whether name can be used as a single YAML path segment
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if ch != '_' && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
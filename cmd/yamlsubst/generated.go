@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/huberp/yamlsubst/pkg/expr"
+)
+
+// persistedCount is the number of generated values the --persist-generated
+// file holds, to write it only when new values were generated
+var persistedCount int
+
+// loadGenerated restores the values recorded in the --persist-generated file
+// into the generator. A missing file holds no values.
+func loadGenerated(g *expr.Generator) error {
+	content, err := os.ReadFile(persistFile) // #nosec G304 -- CLI tool reads user-specified files
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read --persist-generated file: %w", err)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("failed to parse --persist-generated file: %w", err)
+	}
+	for call, value := range values {
+		g.Values[call] = expr.ValueOf(value)
+	}
+	persistedCount = len(g.Values)
+	return nil
+}
+
+// saveGenerated writes the generated values to the --persist-generated file
+// if values were generated since it was last read or written
+func saveGenerated() error {
+	g := subst.Evaluator.Generator
+	if persistFile == "" || checkMode || len(g.Values) == persistedCount {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(g.Values))
+	for call, value := range g.Values {
+		values[call] = value.Interface()
+	}
+	content, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode generated values: %w", err)
+	}
+	content = append([]byte("# Values generated by yamlsubst; keep this file to render the same values again\n"), content...)
+	if err := writeFileAtomic(persistFile, content, existingMode(persistFile, 0o600)); err != nil {
+		return err
+	}
+	persistedCount = len(g.Values)
+	return nil
+}
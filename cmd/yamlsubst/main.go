@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // time zones for in_tz on systems without a zoneinfo database
 
 	"github.com/spf13/cobra"
 
+	"github.com/huberp/yamlsubst/pkg/expr"
 	"github.com/huberp/yamlsubst/pkg/substitutor"
 )
 
@@ -17,12 +25,36 @@ var (
 )
 
 var (
-	yamlFile  string
-	inputFile string
+	yamlFile      string
+	inputFile     string
+	foreachPath   string
+	foreachAs     string
+	foreachOutput string
+	inputDir      string
+	outputDir     string
+	includeGlobs  []string
+	excludeGlobs  []string
+	stripSuffix   string
+	inPlace       bool
+	backupSuffix  string
+	outputFile    string
+	checkMode     bool
+	watchMode     bool
+	watchInterval time.Duration
+	decimalMode   bool
+	decimalScale  int
+	roundingMode  string
+	floatPrec     int
+	nowFlag       string
+	seed          uint64
+	persistFile   string
 )
 
+// subst renders all templates; it is configured from the flags in run
+var subst substitutor.Substitutor
+
 var rootCmd = &cobra.Command{
-	Use:   "yamlsubst",
+	Use:   "yamlsubst [flags] [template...]",
 	Short: "Replace placeholders in input with values from YAML file",
 	Long: `yamlsubst is a CLI tool similar to envsubst that replaces placeholders
 in text input with values from a YAML file.
@@ -31,7 +63,18 @@ Placeholders use the format: ${.path.to.value}
 
 Example:
   echo "Hello ${.name}" | yamlsubst --yaml values.yaml
-  yamlsubst --yaml values.yaml --file template.txt`,
+  yamlsubst --yaml values.yaml --file template.txt
+  yamlsubst --yaml values.yaml --output all.yaml header.tmpl body.tmpl
+  yamlsubst --yaml values.yaml --output-dir out --strip-suffix .tmpl a.yaml.tmpl b.yaml.tmpl
+  yamlsubst --yaml values.yaml --file service.tmpl --foreach .services --foreach-output 'out/${.item.name}.yaml'
+  yamlsubst --yaml values.yaml --file config.yaml --in-place --backup-suffix .bak
+  yamlsubst --yaml values.yaml --input-dir deploy/templates --output-dir deploy/out --include '*.tmpl' --strip-suffix .tmpl
+  yamlsubst --yaml values.yaml --file config.tmpl --output config.yaml --check
+  yamlsubst --yaml values.yaml --file config.tmpl --output config.yaml --watch
+  yamlsubst --yaml prices.yaml --file invoice.tmpl --decimal --decimal-scale 2
+  yamlsubst --yaml build.yaml --file build-info.tmpl --now 2025-01-01T00:00:00Z
+  yamlsubst --yaml dev.yaml --file .env.tmpl --output .env --persist-generated .generated.yaml`,
+	Args: cobra.ArbitraryArgs,
 	RunE: run,
 }
 
@@ -46,47 +89,274 @@ var versionCmd = &cobra.Command{
 func init() {
 	rootCmd.Flags().StringVar(&yamlFile, "yaml", "", "YAML file containing values for substitution (required)")
 	rootCmd.Flags().StringVar(&inputFile, "file", "", "Input file containing placeholders (reads from stdin if not specified)")
+	rootCmd.Flags().StringVar(&foreachPath, "foreach", "", "Render the input once per element of the list (or key of the map) at this YAML path")
+	rootCmd.Flags().StringVar(&foreachAs, "foreach-as", "item", "Name under which the current --foreach element is bound (e.g. ${.item.name})")
+	rootCmd.Flags().StringVar(&foreachOutput, "foreach-output", "", "Output path template for each --foreach result (concatenates the results into --output or stdout if not specified)")
+	rootCmd.Flags().StringVar(&inputDir, "input-dir", "", "Directory tree of templates to render (requires --output-dir)")
+	rootCmd.Flags().StringVar(&outputFile, "output", "", "Output file for the rendered result; multiple inputs and --foreach results are concatenated (writes to stdout if not specified)")
+	rootCmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory receiving the rendered --input-dir tree, or one file per input file")
+	rootCmd.Flags().StringSliceVar(&includeGlobs, "include", nil, "Glob of files to render in --input-dir (default all files, repeatable)")
+	rootCmd.Flags().StringSliceVar(&excludeGlobs, "exclude", nil, "Glob of files not to render in --input-dir; they are copied verbatim (repeatable)")
+	rootCmd.Flags().StringVar(&stripSuffix, "strip-suffix", "", "Suffix removed from rendered file names (e.g. .tmpl)")
+	rootCmd.Flags().BoolVar(&inPlace, "in-place", false, "Overwrite the input file with the rendered output (atomic, keeps mode and ownership)")
+	rootCmd.Flags().StringVar(&backupSuffix, "backup-suffix", "", "With --in-place, keep the original input file with this suffix appended (e.g. .bak)")
+	rootCmd.Flags().BoolVar(&checkMode, "check", false, "Compare rendered output with the existing output files, print a diff and fail if they differ; nothing is written")
+	rootCmd.Flags().BoolVar(&watchMode, "watch", false, "Keep running and re-render whenever the YAML file or a template changes")
+	rootCmd.Flags().DurationVar(&watchInterval, "watch-interval", 500*time.Millisecond, "Polling interval for --watch; changes are rendered once files are stable for one interval")
+	rootCmd.Flags().BoolVar(&decimalMode, "decimal", false, "Evaluate arithmetic with exact decimals instead of floating-point numbers (e.g. for prices)")
+	rootCmd.Flags().IntVar(&decimalScale, "decimal-scale", expr.DefaultDecimalMode.Scale, "With --decimal, number of fractional digits results are rounded to")
+	rootCmd.Flags().StringVar(&roundingMode, "rounding", expr.DefaultDecimalMode.Rounding.String(), "With --decimal, rounding mode: half-up, half-even or down")
+	rootCmd.Flags().StringVar(&nowFlag, "now", "", "Pin the time returned by now(), date and unix to this RFC 3339 timestamp for reproducible output")
+	rootCmd.Flags().Uint64Var(&seed, "seed", 0, "Seed for random_string, random_int and uuid, for reproducible output in tests (default: secure random values)")
+	rootCmd.Flags().StringVar(&persistFile, "persist-generated", "", "YAML file recording generated random values, so that re-rendering yields the same values (e.g. throwaway passwords)")
+	rootCmd.Flags().IntVar(&floatPrec, "float-precision", -1, "Number of fractional digits for non-integer results without a format directive (-1: shortest exact representation)")
 	if err := rootCmd.MarkFlagRequired("yaml"); err != nil {
 		panic(err)
 	}
+	rootCmd.MarkFlagsMutuallyExclusive("input-dir", "file")
+	rootCmd.MarkFlagsMutuallyExclusive("input-dir", "foreach")
+	rootCmd.MarkFlagsMutuallyExclusive("in-place", "input-dir")
+	rootCmd.MarkFlagsMutuallyExclusive("in-place", "foreach")
+	rootCmd.MarkFlagsMutuallyExclusive("in-place", "output", "output-dir")
+	rootCmd.MarkFlagsMutuallyExclusive("output", "output-dir", "foreach-output")
+	rootCmd.MarkFlagsMutuallyExclusive("output-dir", "foreach")
+	rootCmd.MarkFlagsMutuallyExclusive("check", "in-place")
+	rootCmd.MarkFlagsMutuallyExclusive("watch", "check")
+	rootCmd.MarkFlagsMutuallyExclusive("watch", "in-place")
 
 	rootCmd.AddCommand(versionCmd)
 }
 
 func run(cmd *cobra.Command, args []string) error {
+	if err := validateFlags(args); err != nil {
+		return err
+	}
+	if err := configureSubstitutor(cmd); err != nil {
+		return err
+	}
+	// Errors past flag validation are not usage errors
+	cmd.SilenceUsage = true
+
+	if watchMode {
+		return runWatch(cmd.Context(), args)
+	}
+	return renderOnce(args)
+}
+
+// renderOnce loads the values and renders all inputs a single time
+func renderOnce(args []string) error {
 	// Read YAML file
 	yamlContent, err := os.ReadFile(yamlFile) // #nosec G304 -- CLI tool reads user-specified files
 	if err != nil {
 		return fmt.Errorf("failed to read YAML file: %w", err)
 	}
 
-	// Read input
-	var input []byte
-	if inputFile != "" {
-		input, err = os.ReadFile(inputFile) // #nosec G304 -- CLI tool reads user-specified files
+	// Parse values once for all renderings
+	data, err := substitutor.ParseValues(string(yamlContent))
+	if err != nil {
+		return fmt.Errorf("substitution failed: %w", err)
+	}
+
+	// Each rendering gets the random values of the previous one
+	subst.Evaluator.Generator.Rewind()
+	if err := render(data, args); err != nil {
+		return err
+	}
+	if err := saveGenerated(); err != nil {
+		return err
+	}
+	if checkMode && checkedFiles == 0 {
+		return fmt.Errorf("--check compared no output files")
+	}
+	if outdatedFiles > 0 {
+		return fmt.Errorf("%d file(s) out of date", outdatedFiles)
+	}
+	return nil
+}
+
+// render renders the inputs selected by the flags and writes the results
+func render(data interface{}, args []string) error {
+	if inputDir != "" {
+		return runDir(data)
+	}
+
+	// Read all inputs before writing anything
+	templates, err := readTemplates(inputPaths(args))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case inPlace:
+		for _, t := range templates {
+			result := substitute(t.content, data)
+			if err := replaceInPlace(t.path, []byte(result), backupSuffix); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputDir != "":
+		return runMapped(data, templates)
+	}
+
+	input := concatTemplates(templates)
+	if foreachPath != "" {
+		return runForeach(data, input)
+	}
+
+	return writeResult(substitute(input, data))
+}
+
+// writeResult writes a rendering to --output, or to stdout without it
+func writeResult(result string) error {
+	if outputFile != "" {
+		return writeOutput(outputFile, []byte(result), existingMode(outputFile, 0o644))
+	}
+
+	// Output result
+	fmt.Print(result)
+	return nil
+}
+
+// configureSubstitutor sets up expression evaluation, formatting and warnings
+// about invalid placeholders from the flags
+func configureSubstitutor(cmd *cobra.Command) error {
+	subst.Invalid = func(expression string, err error) {
+		fmt.Fprintf(os.Stderr, "warning: invalid placeholder ${%s}: %v\n", expression, err)
+	}
+	if floatPrec >= 0 {
+		subst.FloatFormat = fmt.Sprintf("%%.%df", floatPrec)
+	}
+	if nowFlag != "" {
+		now, err := time.Parse(time.RFC3339, nowFlag)
 		if err != nil {
-			return fmt.Errorf("failed to read input file: %w", err)
+			return fmt.Errorf("invalid --now: %w", err)
 		}
+		subst.Evaluator.Now = now
+	}
+	if cmd.Flags().Changed("seed") {
+		subst.Evaluator.Generator = expr.NewSeededGenerator(seed)
 	} else {
-		input, err = io.ReadAll(os.Stdin)
+		subst.Evaluator.Generator = expr.NewGenerator()
+	}
+	if persistFile != "" {
+		if err := loadGenerated(subst.Evaluator.Generator); err != nil {
+			return err
+		}
+	}
+	if !decimalMode {
+		return nil
+	}
+	if decimalScale < 0 {
+		return fmt.Errorf("--decimal-scale must not be negative")
+	}
+	rounding, err := expr.ParseRounding(roundingMode)
+	if err != nil {
+		return fmt.Errorf("invalid --rounding: %w", err)
+	}
+	subst.Evaluator.Decimal = &expr.DecimalMode{Scale: decimalScale, Rounding: rounding}
+	return nil
+}
+
+// validateFlags checks flag combinations that cobra's flag groups cannot express
+func validateFlags(args []string) error {
+	hasFiles := inputFile != "" || len(args) > 0
+	switch {
+	case foreachOutput != "" && foreachPath == "":
+		return fmt.Errorf("--foreach-output requires --foreach")
+	case inputDir != "" && outputDir == "":
+		return fmt.Errorf("--input-dir requires --output-dir")
+	case inputDir != "" && len(args) > 0:
+		return fmt.Errorf("--input-dir does not accept template arguments")
+	case outputDir != "" && inputDir == "" && !hasFiles:
+		return fmt.Errorf("--output-dir requires --input-dir or input files")
+	case inPlace && !hasFiles:
+		return fmt.Errorf("--in-place requires input files")
+	case backupSuffix != "" && !inPlace:
+		return fmt.Errorf("--backup-suffix requires --in-place")
+	case checkMode && outputFile == "" && outputDir == "" && foreachOutput == "":
+		return fmt.Errorf("--check requires --output, --output-dir or --foreach-output")
+	case watchMode && inputDir == "" && !hasFiles:
+		return fmt.Errorf("--watch requires input files; stdin cannot be re-read")
+	case watchMode && watchInterval <= 0:
+		return fmt.Errorf("--watch-interval must be positive")
+	}
+	return nil
+}
+
+// template is one input read from a file or stdin
+type template struct {
+	path    string // empty for stdin
+	content string
+}
+
+// inputPaths returns the --file input followed by the positional arguments
+func inputPaths(args []string) []string {
+	if inputFile == "" {
+		return args
+	}
+	return append([]string{inputFile}, args...)
+}
+
+// readTemplates reads every input file, or stdin when there are none
+func readTemplates(paths []string) ([]template, error) {
+	if len(paths) == 0 {
+		input, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			return fmt.Errorf("failed to read from stdin: %w", err)
+			return nil, fmt.Errorf("failed to read from stdin: %w", err)
 		}
+		return []template{{content: string(input)}}, nil
 	}
 
-	// Perform substitution
-	result, err := substitutor.Substitute(string(input), string(yamlContent))
-	if err != nil {
-		return fmt.Errorf("substitution failed: %w", err)
+	templates := make([]template, len(paths))
+	for i, p := range paths {
+		input, err := os.ReadFile(p) // #nosec G304 -- CLI tool reads user-specified files
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		templates[i] = template{path: p, content: string(input)}
 	}
+	return templates, nil
+}
 
-	// Output result
-	fmt.Print(result)
+// concatTemplates joins all inputs into a single template
+func concatTemplates(templates []template) string {
+	if len(templates) == 1 {
+		return templates[0].content
+	}
+	var sb strings.Builder
+	for _, t := range templates {
+		sb.WriteString(t.content)
+	}
+	return sb.String()
+}
+
+// runMapped renders each input to a file of the same name in --output-dir
+func runMapped(data interface{}, templates []template) error {
+	outputs := make([]string, len(templates))
+	seen := make(map[string]string, len(templates))
+	for i, t := range templates {
+		outputs[i] = filepath.Join(outputDir, stripOutputSuffix(filepath.Base(t.path)))
+		if prev, ok := seen[outputs[i]]; ok {
+			return fmt.Errorf("inputs %s and %s both map to %s", prev, t.path, outputs[i])
+		}
+		seen[outputs[i]] = t.path
+	}
+
+	for i, t := range templates {
+		result := substitute(t.content, data)
+		if err := writeOutput(outputs[i], []byte(result), existingMode(t.path, 0o644)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }
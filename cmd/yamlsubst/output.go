@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// checkedFiles and outdatedFiles count the outputs compared with their
+// rendering in --check mode and those found to differ
+var checkedFiles, outdatedFiles int
+
+// writeOutput writes content to path with the given permissions, creating parent
+// directories as needed. In --check mode the file is compared instead of written.
+func writeOutput(path string, content []byte, perm os.FileMode) error {
+	if checkMode {
+		return checkOutput(path, content)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return writeFileAtomic(path, content, perm)
+}
+
+// checkOutput compares the file at path with content and prints a unified diff
+// to stdout when they differ. A missing file differs from any content.
+func checkOutput(path string, content []byte) error {
+	checkedFiles++
+	oldName := path
+	current, err := os.ReadFile(path) // #nosec G304 -- CLI tool reads user-specified files
+	if errors.Is(err, fs.ErrNotExist) {
+		oldName = os.DevNull
+	} else if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	if string(current) == string(content) {
+		return nil
+	}
+	outdatedFiles++
+	return writeUnifiedDiff(os.Stdout, oldName, path, string(current), string(content))
+}
+
+// existingMode returns the permissions of the file at path, or fallback if it
+// cannot be determined
+func existingMode(path string, fallback os.FileMode) os.FileMode {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fallback
+	}
+	return info.Mode().Perm()
+}
+
+// writeFileAtomic replaces path with content via a temporary file in the same
+// directory and a rename, so readers never observe a partially written file.
+// The ownership of an existing file is preserved where the platform supports it.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set output file mode: %w", err)
+	}
+	if info, statErr := os.Stat(path); statErr == nil {
+		if err = preserveOwner(tmp, info); err != nil {
+			return fmt.Errorf("failed to preserve file ownership: %w", err)
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace output file: %w", err)
+	}
+	return nil
+}
+
+// replaceInPlace atomically overwrites path with content, keeping its mode and
+// ownership. With a non-empty backupSuffix the original content is saved first.
+func replaceInPlace(path string, content []byte, backupSuffix string) error {
+	// Replace the target of a symlink rather than the link itself
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve input file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	if backupSuffix != "" {
+		original, err := os.ReadFile(path) // #nosec G304 -- CLI tool reads user-specified files
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		if err := writeFileAtomic(path+backupSuffix, original, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+
+	return writeFileAtomic(path, content, info.Mode().Perm())
+}
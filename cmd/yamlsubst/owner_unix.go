@@ -0,0 +1,24 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// preserveOwner gives f the owner and group of the file described by info
+func preserveOwner(f *os.File, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if cur, ok := current.Sys().(*syscall.Stat_t); ok && cur.Uid == stat.Uid && cur.Gid == stat.Gid {
+		return nil
+	}
+	return f.Chown(int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// preserveOwner is a no-op on Windows, where new files inherit the directory ACL
+func preserveOwner(_ *os.File, _ os.FileInfo) error {
+	return nil
+}
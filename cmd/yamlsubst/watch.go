@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchLog reports watch activity on stderr so stdout stays reserved for output
+var watchLog = log.New(os.Stderr, "yamlsubst: ", log.LstdFlags)
+
+// placeholderValues records the values placeholders rendered to during one run.
+// A placeholder rendered several times (e.g. with --foreach) keeps all values in order.
+type placeholderValues map[string][]string
+
+// recorded collects placeholder values while watching; nil otherwise
+var recorded placeholderValues
+
+// substitute renders content with data, recording placeholder values while watching
+func substitute(content string, data interface{}) string {
+	if recorded == nil {
+		return subst.SubstituteData(content, data)
+	}
+
+	// Record the values written, once per placeholder and content
+	s := subst
+	seen := make(map[string]bool)
+	s.Rendered = func(expression, text string) {
+		if !seen[expression] {
+			seen[expression] = true
+			recorded[expression] = append(recorded[expression], text)
+		}
+	}
+	return s.SubstituteData(content, data)
+}
+
+// fileStamp identifies a version of a file for change detection
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+// equal reports whether both stamps describe the same file version
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.missing == other.missing && s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+// runWatch renders once, then polls the watched files and re-renders whenever
+// they change. Changes are debounced until the files are stable for one interval.
+// Render errors are logged and do not stop watching.
+func runWatch(ctx context.Context, args []string) error {
+	stamps := snapshot(watchedFiles(args))
+	previous := watchRender(args, nil)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current := snapshot(watchedFiles(args))
+		if changed := changedFiles(stamps, current); len(changed) > 0 {
+			watchLog.Printf("changed: %s", strings.Join(changed, ", "))
+			stamps = current
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			previous = watchRender(args, previous)
+		}
+	}
+}
+
+// watchRender renders once and logs the placeholders whose values differ from previous
+func watchRender(args []string, previous placeholderValues) placeholderValues {
+	recorded = placeholderValues{}
+	defer func() { recorded = nil }()
+
+	if err := renderOnce(args); err != nil {
+		watchLog.Printf("render failed: %v", err)
+		return previous
+	}
+
+	if previous == nil {
+		watchLog.Printf("rendered, watching for changes")
+		return recorded
+	}
+	logChangedPlaceholders(previous, recorded)
+	return recorded
+}
+
+// logChangedPlaceholders logs every placeholder whose rendered values changed
+func logChangedPlaceholders(previous, current placeholderValues) {
+	changes := 0
+	for _, expression := range sortedKeys(current) {
+		before := strings.Join(previous[expression], ", ")
+		after := strings.Join(current[expression], ", ")
+		if _, ok := previous[expression]; !ok {
+			before = "(new)"
+		}
+		if before != after {
+			watchLog.Printf("  ${%s}: %s -> %s", expression, before, after)
+			changes++
+		}
+	}
+	watchLog.Printf("re-rendered, %d placeholder(s) changed", changes)
+}
+
+// watchedFiles lists the values file and every template
+func watchedFiles(args []string) []string {
+	files := []string{yamlFile}
+	if inputDir == "" {
+		return append(files, inputPaths(args)...)
+	}
+
+	entries, err := collectDir(inputDir, outputDir)
+	if err != nil {
+		// A vanished input directory is reported when rendering
+		return files
+	}
+	for _, e := range entries {
+		files = append(files, filepath.Join(inputDir, filepath.FromSlash(e.rel)))
+	}
+	return files
+}
+
+// snapshot stamps each file; missing files get a distinct stamp
+func snapshot(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			stamps[f] = fileStamp{missing: true}
+			continue
+		}
+		stamps[f] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// changedFiles returns the files added, removed or modified between two snapshots
+func changedFiles(before, after map[string]fileStamp) []string {
+	var changed []string
+	for f, stamp := range after {
+		if prev, ok := before[f]; !ok || !prev.equal(stamp) {
+			changed = append(changed, f)
+		}
+	}
+	for f := range before {
+		if _, ok := after[f]; !ok {
+			changed = append(changed, f)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// sortedKeys returns the keys of values in sorted order
+func sortedKeys(values placeholderValues) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,174 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeValue returns a point in time
+func TimeValue(t time.Time) Value {
+	return Value{kind: KindTime, t: t}
+}
+
+// DateValue returns a point in time that is rendered as a date without a
+// time of day, like the YAML date it was read from (2006-01-02)
+func DateValue(t time.Time) Value {
+	return Value{kind: KindTime, t: t, b: true}
+}
+
+// Time returns the value of a time; the zero time for other kinds
+func (v Value) Time() time.Time {
+	return v.t
+}
+
+// now returns the evaluator's clock: the pinned Now, or the current time
+func (e *Evaluator) now() time.Time {
+	if e.Now.IsZero() {
+		return time.Now()
+	}
+	return e.Now
+}
+
+// timeLayouts are the named layouts accepted wherever a layout is expected
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// strftimeLayouts maps strftime directives to Go layout elements
+var strftimeLayouts = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'Z': "MST", 'z': "-0700", 'F': "2006-01-02", 'T': "15:04:05",
+}
+
+// parseLayouts are tried in order by parse_time without a layout
+var parseLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// layout converts a format to a Go time layout. The format is a named layout
+// such as RFC3339, a strftime format if it contains '%', or a Go layout.
+func layout(format string) (string, error) {
+	if l, ok := timeLayouts[format]; ok {
+		return l, nil
+	}
+	if !strings.Contains(format, "%") {
+		return format, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			sb.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return "", fmt.Errorf("format %q ends with %%", format)
+		}
+		i++
+		if format[i] == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+		l, ok := strftimeLayouts[format[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in format %q", format[i], format)
+		}
+		sb.WriteString(l)
+	}
+	return sb.String(), nil
+}
+
+// timeArg converts a function argument to a time. Strings are parsed as
+// RFC 3339 timestamps, date-times (2006-01-02 15:04:05) or dates.
+func timeArg(arg Value) (time.Time, error) {
+	switch arg.kind {
+	case KindTime:
+		return arg.t, nil
+	case KindString:
+		for _, l := range parseLayouts {
+			if t, err := time.Parse(l, strings.TrimSpace(arg.s)); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("type error: expected a time, got %s", arg.describe())
+}
+
+// date formats a time, or the current time if only a format is given
+func (e *Evaluator) date(args []Value) (Value, error) {
+	t, format := e.now(), args[0]
+	if len(args) == 2 {
+		var err error
+		if t, err = timeArg(args[0]); err != nil {
+			return Value{}, err
+		}
+		format = args[1]
+	}
+	l, err := layout(format.String())
+	if err != nil {
+		return Value{}, err
+	}
+	return StringValue(t.Format(l)), nil
+}
+
+// unix returns the seconds since the Unix epoch of a time, or of the current
+// time without arguments
+func (e *Evaluator) unix(args []Value) (Value, error) {
+	if len(args) == 0 {
+		return IntValue(e.now().Unix()), nil
+	}
+	t, err := timeArg(args[0])
+	return IntValue(t.Unix()), err
+}
+
+// parseTime parses a time in the given format, or in one of parseLayouts
+func parseTime(args []Value) (Value, error) {
+	if len(args) == 1 {
+		t, err := timeArg(StringValue(args[0].String()))
+		return TimeValue(t), err
+	}
+	l, err := layout(args[1].String())
+	if err != nil {
+		return Value{}, err
+	}
+	t, err := time.Parse(l, args[0].String())
+	if err != nil {
+		return Value{}, err
+	}
+	return TimeValue(t), nil
+}
+
+// addDuration adds a duration, which may be negative, to a time
+func addDuration(args []Value) (Value, error) {
+	t, err := timeArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	d, ok := args[1].duration()
+	if !ok {
+		return Value{}, fmt.Errorf("type error: expected a duration, got %s", args[1].describe())
+	}
+	return TimeValue(t.Add(d)), nil
+}
+
+// inTZ converts a time to the named IANA time zone, e.g. Europe/Berlin
+func inTZ(args []Value) (Value, error) {
+	t, err := timeArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	loc, err := time.LoadLocation(args[1].String())
+	if err != nil {
+		return Value{}, err
+	}
+	return TimeValue(t.In(loc)), nil
+}
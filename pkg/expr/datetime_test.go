@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestEvaluator_Time(t *testing.T) {
+	built := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	resolver := valueResolver(map[string]interface{}{
+		".built": built,
+		".date":  DateValue(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)),
+		".day":   "2024-03-05",
+		".ttl":   "90m",
+		".name":  "api",
+	})
+	e := &Evaluator{Now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"now", "now()", "2025-01-01T12:00:00+01:00"},
+		{"yaml timestamp", ".built", "2024-03-05T10:00:00Z"},
+		{"yaml date", ".date", "2024-03-05"},
+		{"yaml date arithmetic", "add_duration(.date, 36h)", "2024-03-06T12:00:00Z"},
+		{"date go layout", `date("2006-01-02")`, "2025-01-01"},
+		{"date strftime", `date("%Y%m%d-%H%M%S")`, "20250101-120000"},
+		{"date strftime names", `date("%a %d %b %Y, %I:%M %p %Z")`, "Wed 01 Jan 2025, 12:00 PM CET"},
+		{"date literal percent", `date("%d%%")`, "01%"},
+		{"date named layout", `date("RFC1123")`, "Wed, 01 Jan 2025 12:00:00 CET"},
+		{"date of time", `date(.built, "%F %T")`, "2024-03-05 10:00:00"},
+		{"unix", "unix()", "1735729200"},
+		{"unix of time", "unix(.built)", "1709632800"},
+		{"parse_time", `parse_time("2024-03-05T10:00:00+02:00")`, "2024-03-05T10:00:00+02:00"},
+		{"parse_time date", `parse_time(.day)`, "2024-03-05T00:00:00Z"},
+		{"parse_time layout", `parse_time("05/03/2024", "%d/%m/%Y")`, "2024-03-05T00:00:00Z"},
+		{"add_duration", "add_duration(.built, .ttl)", "2024-03-05T11:30:00Z"},
+		{"add negative duration", "add_duration(.built, -1h)", "2024-03-05T09:00:00Z"},
+		{"in_tz", `in_tz(.built, "Europe/Berlin")`, "2024-03-05T11:00:00+01:00"},
+		{"in_tz UTC", `in_tz(now(), "UTC")`, "2025-01-01T11:00:00Z"},
+		{"compare", ".built < now()", "true"},
+		{"equal across zones", `now() == parse_time("2025-01-01T11:00:00Z")`, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Eval(mustParse(t, tt.input), resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_TimeFilters(t *testing.T) {
+	e := &Evaluator{Now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	got, err := e.ApplyFilter("date", StringValue("2024-03-05"), []Value{StringValue("02.01.2006")})
+	if err != nil || got.String() != "05.03.2024" {
+		t.Errorf("date filter: got %q, %v", got.String(), err)
+	}
+	got, err = e.ApplyFilter("unix", TimeValue(e.Now), nil)
+	if err != nil || got.String() != "1735732800" {
+		t.Errorf("unix filter: got %q, %v", got.String(), err)
+	}
+}
+
+func TestEvaluator_TimeErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".name": "api",
+		".ttl":  "90m",
+	})
+	e := &Evaluator{Now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"not a time", "unix(.name)", `unix: type error: expected a time, got string "api"`},
+		{"unsupported directive", `date("%Q")`, `date: unsupported directive %Q in format "%Q"`},
+		{"trailing percent", `date("%Y%")`, `date: format "%Y%" ends with %`},
+		{"unparsable", `parse_time("yesterday")`, `parse_time: type error: expected a time, got string "yesterday"`},
+		{"not a duration", "add_duration(now(), .name)", `add_duration: type error: expected a duration, got string "api"`},
+		{"unknown zone", `in_tz(now(), "Mars/Olympus")`, "in_tz: unknown time zone Mars/Olympus"},
+		{"too many arguments", "now(1)", "function now expects 0 arguments, got 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err == nil {
+				_, err = e.Eval(node, resolver)
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_ClockDefaultsToSystemTime(t *testing.T) {
+	before := time.Now().Unix()
+	got, err := EvalValue(mustParse(t, "unix()"), valueResolver(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Int() < before || got.Int() > time.Now().Unix() {
+		t.Errorf("unix() = %d, want the current time", got.Int())
+	}
+}
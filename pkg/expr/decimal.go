@@ -0,0 +1,203 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Rounding selects how decimal results are rounded to the scale of a DecimalMode
+type Rounding int
+
+const (
+	// RoundHalfUp rounds to the nearest value, halves away from zero (2.345 -> 2.35)
+	RoundHalfUp Rounding = iota
+	// RoundHalfEven rounds to the nearest value, halves to the even neighbour (2.345 -> 2.34)
+	RoundHalfEven
+	// RoundDown truncates towards zero (2.349 -> 2.34)
+	RoundDown
+)
+
+// String returns the name of the rounding mode as accepted by ParseRounding
+func (r Rounding) String() string {
+	switch r {
+	case RoundHalfUp:
+		return "half-up"
+	case RoundHalfEven:
+		return "half-even"
+	case RoundDown:
+		return "down"
+	default:
+		return fmt.Sprintf("rounding(%d)", int(r))
+	}
+}
+
+// ParseRounding parses a rounding mode name: half-up, half-even or down
+func ParseRounding(name string) (Rounding, error) {
+	for _, r := range []Rounding{RoundHalfUp, RoundHalfEven, RoundDown} {
+		if r.String() == name {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q (expected half-up, half-even or down)", name)
+}
+
+// DecimalMode configures decimal arithmetic: results are exact decimals
+// rounded to Scale fractional digits with the given Rounding
+type DecimalMode struct {
+	Scale    int
+	Rounding Rounding
+}
+
+// DefaultDecimalMode is used for decimal values outside an Evaluator with
+// decimal mode, e.g. in function arguments
+var DefaultDecimalMode = DecimalMode{Scale: 10, Rounding: RoundHalfUp}
+
+// maxDecimalExponent limits exact decimal powers; larger exponents use float64
+const maxDecimalExponent = 1000
+
+// DecimalValue returns a decimal value. The value is not copied.
+func DecimalValue(d *big.Rat) Value {
+	return Value{kind: KindDecimal, d: d}
+}
+
+// rat returns a numeric value as an exact rational. Floats are converted via
+// their shortest decimal representation, so 0.1 becomes exactly 1/10.
+func (v Value) rat() *big.Rat {
+	switch v.kind {
+	case KindInt:
+		return new(big.Rat).SetInt64(v.i)
+	case KindDecimal:
+		return v.d
+	case KindFloat:
+		if r, ok := new(big.Rat).SetString(strconv.FormatFloat(v.f, 'g', -1, 64)); ok {
+			return r
+		}
+	}
+	return new(big.Rat)
+}
+
+// decimalOp applies an arithmetic operator to two numbers with decimal arithmetic
+func decimalOp(mode DecimalMode, op string, left, right Value) (Value, error) {
+	if left.Kind() == KindFloat && (math.IsInf(left.f, 0) || math.IsNaN(left.f)) ||
+		right.Kind() == KindFloat && (math.IsInf(right.f, 0) || math.IsNaN(right.f)) {
+		return Value{}, fmt.Errorf("type error: cannot apply %s to %s and %s in decimal mode", op, left.describe(), right.describe())
+	}
+	l, r := left.rat(), right.rat()
+	result := new(big.Rat)
+	switch op {
+	case "+":
+		result.Add(l, r)
+	case "-":
+		result.Sub(l, r)
+	case "*":
+		result.Mul(l, r)
+	case "/", "//", "%":
+		if r.Sign() == 0 {
+			return Value{}, fmt.Errorf("division by zero")
+		}
+		result.Quo(l, r)
+		if op == "//" || op == "%" {
+			result.Set(ratFloor(result))
+		}
+		if op == "%" {
+			result.Sub(l, result.Mul(result, r))
+		}
+	case "**":
+		if !r.IsInt() || r.Num().CmpAbs(big.NewInt(maxDecimalExponent)) > 0 {
+			f, err := applyOp(op, left.Float(), right.Float())
+			if err != nil {
+				return Value{}, err
+			}
+			return FloatValue(f), nil
+		}
+		exp := r.Num().Int64()
+		if exp < 0 && l.Sign() == 0 {
+			return Value{}, fmt.Errorf("division by zero")
+		}
+		num := new(big.Int).Exp(l.Num(), big.NewInt(abs64(exp)), nil)
+		den := new(big.Int).Exp(l.Denom(), big.NewInt(abs64(exp)), nil)
+		if exp < 0 {
+			num, den = den, num
+		}
+		result.SetFrac(num, den)
+	default:
+		return Value{}, fmt.Errorf("unknown operator: %s", op)
+	}
+	return DecimalValue(roundRat(result, mode.Scale, mode.Rounding)), nil
+}
+
+// abs64 returns the absolute value of i
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// ratFloor returns the largest integer less than or equal to r
+func ratFloor(r *big.Rat) *big.Rat {
+	// Euclidean division rounds down for the always positive denominator
+	return new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))
+}
+
+// roundRat rounds r to scale fractional digits (a negative scale rounds to
+// tens, hundreds, ...). Values that already fit are returned unchanged.
+func roundRat(r *big.Rat, scale int, rounding Rounding) *big.Rat {
+	factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(abs64(int64(scale))), nil))
+	if scale < 0 {
+		factor.Inv(factor)
+	}
+	scaled := new(big.Rat).Mul(r, factor)
+	if scaled.IsInt() {
+		return r
+	}
+
+	// Truncate towards zero, then decide whether to round away from zero
+	q, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	half := new(big.Int).Mul(rem.Abs(rem), big.NewInt(2)).Cmp(scaled.Denom())
+	away := false
+	switch rounding {
+	case RoundHalfUp:
+		away = half >= 0
+	case RoundHalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(scaled.Sign())))
+	}
+	return new(big.Rat).Quo(new(big.Rat).SetInt(q), factor)
+}
+
+// formatDecimal formats a decimal with as many fractional digits as needed
+func formatDecimal(d *big.Rat) string {
+	return d.FloatString(fractionDigits(d))
+}
+
+// fractionDigits returns the number of fractional digits of d's exact decimal
+// representation
+func fractionDigits(d *big.Rat) int {
+	// A reduced fraction has a finite decimal expansion if its denominator
+	// only has the prime factors 2 and 5; the digit count is the larger exponent.
+	den := new(big.Int).Set(d.Denom())
+	digits := 0
+	for _, p := range []int64{2, 5} {
+		n := 0
+		prime, m := big.NewInt(p), new(big.Int)
+		for {
+			q, r := new(big.Int).QuoRem(den, prime, m)
+			if r.Sign() != 0 {
+				break
+			}
+			den = q
+			n++
+		}
+		digits = max(digits, n)
+	}
+	if den.Cmp(big.NewInt(1)) != 0 {
+		// Not a finite decimal; only possible for values built outside decimalOp
+		digits = DefaultDecimalMode.Scale
+	}
+	return digits
+}
@@ -0,0 +1,138 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEvaluator_Decimal(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".a":     0.1,
+		".b":     0.2,
+		".price": 19.99,
+		".qty":   3,
+		".rate":  "0.07",
+		".max":   int64(9223372036854775807),
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		scale    int
+		rounding Rounding
+		want     string
+	}{
+		{"exact addition", ".a + .b", 10, RoundHalfUp, "0.3"},
+		{"exact multiplication", ".price * 1.15", 10, RoundHalfUp, "22.9885"},
+		{"rounded to scale", ".price * 1.15", 2, RoundHalfUp, "22.99"},
+		{"numeric string", ".price * .qty * .rate", 2, RoundHalfUp, "4.2"},
+		{"division", "1 / 3", 10, RoundHalfUp, "0.3333333333"},
+		{"exact int division stays int", "10 / 5", 2, RoundHalfUp, "2"},
+		{"int division with remainder", "10 / 4", 2, RoundHalfUp, "2.5"},
+		{"half-up", "2.345 * 1", 2, RoundHalfUp, "2.35"},
+		{"half-up negative", "-2.345 * 1", 2, RoundHalfUp, "-2.35"},
+		{"half-even down", "2.345 * 1", 2, RoundHalfEven, "2.34"},
+		{"half-even up", "2.355 * 1", 2, RoundHalfEven, "2.36"},
+		{"down", "2.349 * 1", 2, RoundDown, "2.34"},
+		{"down negative", "-2.349 * 1", 2, RoundDown, "-2.34"},
+		{"zero scale", "7 / 2", 0, RoundHalfEven, "4"},
+		{"integer division", "7.5 // 2", 2, RoundHalfUp, "3"},
+		{"modulo", "-7.5 % 2", 2, RoundHalfUp, "0.5"},
+		{"power", "1.1 ** 2", 10, RoundHalfUp, "1.21"},
+		{"negative power", "2 ** -2", 10, RoundHalfUp, "0.25"},
+		{"fractional power uses float", "4 ** 0.5", 10, RoundHalfUp, "2"},
+		{"no overflow", ".max + 1", 0, RoundHalfUp, "9223372036854775808"},
+		{"unary minus", "-(.a + .b)", 10, RoundHalfUp, "-0.3"},
+		{"comparison", ".a + .b == 0.3", 10, RoundHalfUp, "true"},
+		{"ordering", ".a + .b < 0.31", 10, RoundHalfUp, "true"},
+		{"round function", "round(.price * 1.15, 1)", 10, RoundHalfUp, "23"},
+		{"round many digits", "round(.price * 1.15, 400)", 10, RoundHalfUp, "22.9885"},
+		{"round many negative digits", "round(.price * 1.15, -400)", 10, RoundHalfUp, "0"},
+		{"floor function", "floor(-(.a + .b))", 10, RoundHalfUp, "-1"},
+		{"ceil function", "ceil(.a + .b)", 10, RoundHalfUp, "1"},
+		{"abs function", "abs(.a - .b)", 10, RoundHalfUp, "0.1"},
+		{"max function", "max(.a + .b, 0.29)", 10, RoundHalfUp, "0.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			e := &Evaluator{Decimal: &DecimalMode{Scale: tt.scale, Rounding: tt.rounding}}
+			got, err := e.Eval(node, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got.describe(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_DecimalErrors(t *testing.T) {
+	e := &Evaluator{Decimal: &DefaultDecimalMode}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1.5 / 0", "division by zero"},
+		{"1.5 // 0", "division by zero"},
+		{"1.5 % 0.0", "division by zero"},
+		{"0 ** -1", "division by zero"},
+		{`1.5 + "a"`, `type error: cannot apply + to float 1.5 and string "a"`},
+	}
+
+	for _, tt := range tests {
+		_, err := e.Eval(mustParse(t, tt.input), nil)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestEvalValue_WithoutDecimalMode(t *testing.T) {
+	got, err := ParseAndEvalValue("0.1 + 0.2", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Kind() != KindFloat || got.String() != "0.30000000000000004" {
+		t.Errorf("got %s, want float 0.30000000000000004", got.describe())
+	}
+}
+
+func TestDecimalValue(t *testing.T) {
+	d := DecimalValue(big.NewRat(-5, 4))
+	if d.Kind() != KindDecimal || d.String() != "-1.25" {
+		t.Errorf("got %s, want decimal -1.25", d.describe())
+	}
+	if d.Float() != -1.25 || d.Int() != -1 {
+		t.Errorf("got Float %v, Int %v", d.Float(), d.Int())
+	}
+	if got := FloatValue(0.1).Decimal(); got.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("Decimal of 0.1 = %s, want 1/10", got)
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	for _, r := range []Rounding{RoundHalfUp, RoundHalfEven, RoundDown} {
+		got, err := ParseRounding(r.String())
+		if err != nil || got != r {
+			t.Errorf("ParseRounding(%q) = %v, %v", r.String(), got, err)
+		}
+	}
+	if _, err := ParseRounding("up"); err == nil {
+		t.Error("expected error for unknown rounding mode")
+	}
+}
+
+func mustParse(t *testing.T, input string) Node {
+	t.Helper()
+	node, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	return node
+}
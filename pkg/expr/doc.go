@@ -1,8 +1,17 @@
 // Package expr provides an arithmetic expression parser and evaluator.
-// It supports basic arithmetic operations (+, -, *, /) with proper operator
-// precedence and parentheses. Expressions can contain hardcoded numbers
-// (integers and floats), YAML references starting with a dot, and environment
-// variable references starting with a dollar sign.
+// It supports arithmetic (+, -, *, /, //, %, **), bitwise (&, |, ^, ~, <<,
+// >>), comparison, logical, conditional (?:) and null-coalescing (??)
+// operators with proper operator precedence and parentheses. Bitwise or is
+// only available inside parentheses or call arguments; at the top level of a
+// placeholder '|' starts a filter. Expressions can contain hardcoded numbers
+// (integers and floats), double-quoted string literals, the literals true,
+// false and null, YAML references starting with a dot, environment variable
+// references starting with a dollar sign, and calls of built-in string and
+// math functions such as upper(.name) or max(.a, .b).
+//
+// Placeholder content may additionally pipe the expression through filters,
+// e.g. `.name | trim | upper`, and end with a format directive such as
+// `.price :%.2f`. See ParsePlaceholder, ApplyFilter and Format.
 //
 // Example expressions:
 //   - "5 + 3" -> 8
@@ -10,6 +19,23 @@
 //   - ".width * .height" -> evaluates YAML references
 //   - "$PORT + 1000" -> evaluates environment variable
 //   - "(.base + $OFFSET) * 2" -> complex expression with both types
+//   - `replace(.name, "-", "_")` -> string function, evaluated with EvalValue
+//   - "clamp(round(.memory * 0.75), 256, 4096)" -> math functions
+//
+// Values are typed (see Value): null, bool, int, float, duration, quantity,
+// time, string, list and map. Strings in time.ParseDuration format, such as
+// "30s", are durations in arithmetic, and Kubernetes quantities such as
+// "512Mi" are quantities; both can also be written as literals (1h30m, 2Gi).
+// A number with the suffix m (minutes or millis) takes its meaning from the
+// other operand and otherwise needs duration(x) or quantity(x).
+// Arithmetic operators accept ints, floats and numeric strings and report a
+// type error for anything else. Eval and ParseAndEval remain as a numeric API
+// for float64 resolvers. An Evaluator with a DecimalMode evaluates arithmetic
+// with exact decimals instead of float64, an Evaluator with Now set pins
+// the clock of the date and time functions, and an Evaluator with a Generator
+// records (or, seeded, reproduces) the values of the random functions. Random
+// functions generate a new value for every call; calls with a name argument
+// share one value.
 //
 // Usage:
 //
@@ -32,4 +58,10 @@
 //
 //	// Format the result
 //	formatted := expr.FormatResult(result) // "14"
+//
+//	// Evaluate expressions with typed values
+//	value, err := expr.EvalValue(node, func(ref string) (expr.Value, error) {
+//		return expr.ValueOf(rawYAMLValue), nil
+//	})
+//	text := value.String()
 package expr
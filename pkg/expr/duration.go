@@ -0,0 +1,126 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DurationValue returns a duration value
+func DurationValue(d time.Duration) Value {
+	return Value{kind: KindDuration, i: int64(d)}
+}
+
+// Duration returns the value of a duration; 0 for other kinds
+func (v Value) Duration() time.Duration {
+	if v.kind != KindDuration {
+		return 0
+	}
+	return time.Duration(v.i)
+}
+
+// duration returns the value as a duration. Strings in time.ParseDuration
+// format (30s, 1h30m) are converted unless they are plain numbers.
+func (v Value) duration() (time.Duration, bool) {
+	switch v.kind {
+	case KindDuration:
+		return time.Duration(v.i), true
+	case KindString:
+		if _, ok := v.numeric(); ok {
+			return 0, false
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(v.s)); err == nil {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// durationOp applies an arithmetic operator to a duration and another
+// duration or a number. ok is false if neither operand is a duration.
+func durationOp(op string, left, right Value) (result Value, ok bool, err error) {
+	l, lok := left.duration()
+	r, rok := right.duration()
+	if !lok && !rok {
+		return Value{}, false, nil
+	}
+
+	if lok && rok {
+		switch op {
+		case "+":
+			if sum, ok := addInt(int64(l), int64(r)); ok {
+				return DurationValue(time.Duration(sum)), true, nil
+			}
+		case "-":
+			if diff, ok := subInt(int64(l), int64(r)); ok {
+				return DurationValue(time.Duration(diff)), true, nil
+			}
+		case "/", "//":
+			// The ratio of two durations is a number
+			result, err := binaryOp(nil, op, IntValue(int64(l)), IntValue(int64(r)))
+			return result, true, err
+		case "%":
+			if r == 0 {
+				return Value{}, true, fmt.Errorf("division by zero")
+			}
+			return DurationValue(l % r), true, nil
+		default:
+			return Value{}, true, typeError(op, left, right)
+		}
+		return Value{}, true, fmt.Errorf("duration overflow: %s %s %s", l, op, r)
+	}
+
+	// Scale a duration by a number: d * n, n * d or d / n
+	d, n := l, right
+	if rok {
+		d, n = r, left
+	}
+	factor, isNumber := n.numeric()
+	if !isNumber || (op != "*" && (op != "/" || rok)) {
+		return Value{}, true, typeError(op, left, right)
+	}
+	if op == "/" && compareNumbers(factor, IntValue(0)) == 0 {
+		return Value{}, true, fmt.Errorf("division by zero")
+	}
+	if op == "*" && factor.Kind() == KindInt {
+		if product, ok := mulInt(int64(d), factor.i); ok {
+			return DurationValue(time.Duration(product)), true, nil
+		}
+		return Value{}, true, fmt.Errorf("duration overflow: %s * %s", d, factor)
+	}
+
+	ns := float64(d) * factor.Float()
+	if op == "/" {
+		ns = float64(d) / factor.Float()
+	}
+	ns = math.Round(ns)
+	if ns < math.MinInt64 || ns >= math.MaxInt64 {
+		return Value{}, true, fmt.Errorf("duration overflow: %s %s %s", d, op, factor)
+	}
+	return DurationValue(time.Duration(ns)), true, nil
+}
+
+// toDuration converts a duration or a string in duration format, such as
+// 30m, to a duration
+func toDuration(args []Value) (Value, error) {
+	d, ok := args[0].duration()
+	if !ok {
+		return Value{}, fmt.Errorf("type error: expected a duration, got %s", args[0].describe())
+	}
+	return DurationValue(d), nil
+}
+
+// durationUnit converts a duration to a number of units, an int if exact
+func durationUnit(unit time.Duration) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		d, ok := args[0].duration()
+		if !ok {
+			return Value{}, fmt.Errorf("type error: expected a duration, got %s", args[0].describe())
+		}
+		if d%unit == 0 {
+			return IntValue(int64(d / unit)), nil
+		}
+		return FloatValue(float64(d) / float64(unit)), nil
+	}
+}
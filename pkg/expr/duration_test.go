@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvalValue_Durations(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".timeout":  "30s",
+		".interval": "1h30m",
+		".retries":  3,
+		".port":     "8080",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"multiply", ".timeout * 2", DurationValue(time.Minute)},
+		{"multiply left", ".retries * .timeout", DurationValue(90 * time.Second)},
+		{"multiply float", ".timeout * 1.5", DurationValue(45 * time.Second)},
+		{"add", ".timeout + .interval", DurationValue(90*time.Minute + 30*time.Second)},
+		{"subtract", ".interval - .timeout", DurationValue(89*time.Minute + 30*time.Second)},
+		{"divide by number", ".interval / 4", DurationValue(22*time.Minute + 30*time.Second)},
+		{"ratio", ".interval / .timeout", IntValue(180)},
+		{"fractional ratio", ".timeout / 1m", FloatValue(0.5)},
+		{"integer ratio", "100s // .timeout", IntValue(3)},
+		{"remainder", "100s % .timeout", DurationValue(10 * time.Second)},
+		{"negate", "-.timeout", DurationValue(-30 * time.Second)},
+		{"literal", "500ms + 1s", DurationValue(1500 * time.Millisecond)},
+		{"compare", ".timeout < 1m", BoolValue(true)},
+		{"compare strings as durations", `.interval > "2m"`, BoolValue(true)},
+		{"equal", `.timeout == "0.5m"`, BoolValue(true)},
+		{"numeric string stays a number", ".port + 1", IntValue(8081)},
+		{"seconds", "seconds(.timeout)", IntValue(30)},
+		{"fractional seconds", "seconds(1500ms)", FloatValue(1.5)},
+		{"millis", "millis(.interval)", IntValue(5400000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_DurationErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".timeout": "30s",
+		".name":    "api",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"add number", ".timeout + 1", `type error: cannot apply + to string "30s" and int 1`},
+		{"multiply durations", ".timeout * 2s", `type error: cannot apply * to string "30s" and duration 2s`},
+		{"divide number by duration", "60 / .timeout", `type error: cannot apply / to int 60 and string "30s"`},
+		{"divide by zero", ".timeout / 0", "division by zero"},
+		{"remainder by zero", ".timeout % 0s", "division by zero"},
+		{"overflow", "2540400h * 2", "duration overflow: 2540400h0m0s * 2"},
+		{"seconds of string", "seconds(.name)", `seconds: type error: expected a duration, got string "api"`},
+		{"seconds of number", "seconds(30)", "seconds: type error: expected a duration, got int 30"},
+		{"invalid literal", "30x", "invalid number: 30x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package expr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stringFunction adapts a string transformation to a one-argument function
+func stringFunction(fn func(s string) string) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		return StringValue(fn(args[0].String())), nil
+	}
+}
+
+// b64enc encodes s as standard base64, e.g. for Kubernetes Secret data
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// b64dec decodes standard base64, with or without padding
+func b64dec(args []Value) (Value, error) {
+	s := strings.TrimSpace(args[0].String())
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if b, err = base64.RawStdEncoding.DecodeString(s); err != nil {
+			return Value{}, fmt.Errorf("invalid base64: %q", args[0].String())
+		}
+	}
+	return StringValue(string(b)), nil
+}
+
+// hexEncode encodes the bytes of s as lowercase hexadecimal
+func hexEncode(s string) string {
+	return hex.EncodeToString([]byte(s))
+}
+
+// urlQuery percent-encodes s for a URL query parameter (spaces become +)
+func urlQuery(s string) string {
+	return url.QueryEscape(s)
+}
+
+// urlPath percent-encodes s for a URL path segment (spaces become %20)
+func urlPath(s string) string {
+	return url.PathEscape(s)
+}
+
+// htmlEscape escapes <, >, &, ' and " for HTML
+func htmlEscape(s string) string {
+	return html.EscapeString(s)
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsonQuote quotes its argument as a JSON string. <, > and & are not escaped.
+func jsonQuote(args []Value) (Value, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(args[0].String()); err != nil {
+		return Value{}, err
+	}
+	return StringValue(strings.TrimSuffix(buf.String(), "\n")), nil
+}
+
+// yamlQuote quotes its argument as a double-quoted YAML scalar, which keeps values such
+// as yes, 1.0 or null strings
+func yamlQuote(args []Value) (Value, error) {
+	node := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: args[0].String(), Style: yaml.DoubleQuotedStyle}
+	b, err := yaml.Marshal(&node)
+	if err != nil {
+		return Value{}, err
+	}
+	return StringValue(strings.TrimSuffix(string(b), "\n")), nil
+}
@@ -0,0 +1,56 @@
+package expr
+
+import "testing"
+
+func TestEncodingFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".password": "s3cr3t!",
+		".query":    "a b&c=d/é",
+		".quote":    `it's "quoted" <b>`,
+		".port":     8080,
+		".flag":     "yes",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"b64enc", "b64enc(.password)", "czNjcjN0IQ=="},
+		{"b64dec", `b64dec("czNjcjN0IQ==")`, "s3cr3t!"},
+		{"b64dec unpadded", `b64dec("czNjcjN0IQ")`, "s3cr3t!"},
+		{"b64 round trip", "b64dec(b64enc(.quote))", `it's "quoted" <b>`},
+		{"b64enc number", "b64enc(.port)", "ODA4MA=="},
+		{"hex", `hex("hi!")`, "686921"},
+		{"urlquery", "urlquery(.query)", "a+b%26c%3Dd%2F%C3%A9"},
+		{"urlpath", "urlpath(.query)", "a%20b&c=d%2F%C3%A9"},
+		{"html_escape", "html_escape(.quote)", "it&#39;s &#34;quoted&#34; &lt;b&gt;"},
+		{"shell_quote", "shell_quote(.quote)", `'it'\''s "quoted" <b>'`},
+		{"shell_quote empty", `shell_quote("")`, "''"},
+		{"json_quote", "json_quote(.quote)", `"it's \"quoted\" <b>"`},
+		{"json_quote control", `json_quote("a\nb\tc")`, `"a\nb\tc"`},
+		{"yaml_quote", "yaml_quote(.flag)", `"yes"`},
+		{"yaml_quote escapes", `yaml_quote("a\"b\nc")`, `"a\"b\nc"`},
+		{"yaml_quote number", "yaml_quote(.port)", `"8080"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	_, err := ParseAndEvalValue(`b64dec("not base64!")`, valueResolver(nil))
+	want := `b64dec: invalid base64: "not base64!"`
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
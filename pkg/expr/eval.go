@@ -0,0 +1,504 @@
+package expr
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is wrapped by resolvers for references that do not resolve.
+// The ?? operator falls back to its right operand on such errors.
+var ErrNotFound = errors.New("not found")
+
+// errIntOverflow reports an integer result beyond int64
+var errIntOverflow = errors.New("integer overflow")
+
+// ValueResolver resolves a reference path (.yaml.path or $ENV_VAR) to its value
+type ValueResolver func(ref string) (Value, error)
+
+// Evaluator evaluates expressions with configurable arithmetic, clock and
+// randomness. The zero Evaluator uses int64 and float64 arithmetic, the
+// system clock and a cryptographically secure random source.
+type Evaluator struct {
+	// Decimal, if set, evaluates arithmetic that is not exact in int64 with
+	// arbitrary-precision decimals instead of float64
+	Decimal *DecimalMode
+	// Now, if set, pins the clock read by now(), date and unix, e.g. for
+	// reproducible output
+	Now time.Time
+	// Generator, if set, produces and records the values of random_string,
+	// random_int and uuid, e.g. seeded for reproducible output. Without one,
+	// values are not recorded, so named calls do not share their value.
+	Generator *Generator
+}
+
+// EvalValue evaluates the expression with typed values. Arithmetic operators
+// accept ints, floats and numeric strings; int op int stays an int except for
+// division. Other operand types are reported as type errors.
+func EvalValue(node Node, resolver ValueResolver) (Value, error) {
+	return (&Evaluator{}).Eval(node, resolver)
+}
+
+// Eval evaluates the expression like EvalValue, using the evaluator's arithmetic
+func (e *Evaluator) Eval(node Node, resolver ValueResolver) (Value, error) {
+	switch n := node.(type) {
+	case *NumberNode:
+		return n.value(), nil
+
+	case *StringNode:
+		return StringValue(n.Value), nil
+
+	case *LiteralNode:
+		return n.Value, nil
+
+	case *ReferenceNode:
+		return resolver(n.Path)
+
+	case *FuncCallNode:
+		args := make([]Value, len(n.Args))
+		for i, arg := range n.Args {
+			value, err := e.Eval(arg, resolver)
+			if err != nil {
+				return Value{}, err
+			}
+			args[i] = value
+		}
+		return e.callFunction(n.Name, args)
+
+	case *UnaryOpNode:
+		operand, err := e.Eval(n.Operand, resolver)
+		if err != nil {
+			return Value{}, err
+		}
+		return unaryOp(n.Op, operand)
+
+	case *ConditionalNode:
+		cond, err := e.Eval(n.Cond, resolver)
+		if err != nil {
+			return Value{}, err
+		}
+		ok, err := cond.truth()
+		if err != nil {
+			return Value{}, fmt.Errorf("condition: %w", err)
+		}
+		if ok {
+			return e.Eval(n.Then, resolver)
+		}
+		return e.Eval(n.Else, resolver)
+
+	case *BinaryOpNode:
+		switch n.Op {
+		case "??", "&&", "||":
+			return e.shortCircuitOp(n, resolver)
+		}
+		left, err := e.Eval(n.Left, resolver)
+		if err != nil {
+			return Value{}, err
+		}
+		right, err := e.Eval(n.Right, resolver)
+		if err != nil {
+			return Value{}, err
+		}
+		return binaryOp(e.Decimal, n.Op, left, right)
+
+	default:
+		return Value{}, fmt.Errorf("unknown node type")
+	}
+}
+
+// ParseAndEvalValue is a convenience function that parses and evaluates an expression with typed values
+func ParseAndEvalValue(input string, resolver ValueResolver) (Value, error) {
+	node, err := Parse(input)
+	if err != nil {
+		return Value{}, err
+	}
+	return EvalValue(node, resolver)
+}
+
+// shortCircuitOp evaluates ??, && and ||, which only evaluate their right
+// operand when the left one does not decide the result
+func (e *Evaluator) shortCircuitOp(n *BinaryOpNode, resolver ValueResolver) (Value, error) {
+	left, err := e.Eval(n.Left, resolver)
+	if n.Op == "??" {
+		if (err != nil && errors.Is(err, ErrNotFound)) || (err == nil && left.IsNull()) {
+			return e.Eval(n.Right, resolver)
+		}
+		return left, err
+	}
+	if err != nil {
+		return Value{}, err
+	}
+
+	l, err := left.truth()
+	if err != nil {
+		return Value{}, fmt.Errorf("%s: %w", n.Op, err)
+	}
+	if (n.Op == "&&" && !l) || (n.Op == "||" && l) {
+		return BoolValue(l), nil
+	}
+
+	right, err := e.Eval(n.Right, resolver)
+	if err != nil {
+		return Value{}, err
+	}
+	r, err := right.truth()
+	if err != nil {
+		return Value{}, fmt.Errorf("%s: %w", n.Op, err)
+	}
+	return BoolValue(r), nil
+}
+
+// unaryOp applies a prefix operator to a value
+func unaryOp(op string, operand Value) (Value, error) {
+	switch op {
+	case "!":
+		b, err := operand.truth()
+		if err != nil {
+			return Value{}, fmt.Errorf("!: %w", err)
+		}
+		return BoolValue(!b), nil
+	case "-", "+":
+		if operand.ambiguous() {
+			return Value{}, ambiguousError(operand)
+		}
+		if d, ok := operand.duration(); ok {
+			if op == "-" {
+				if d == math.MinInt64 {
+					return Value{}, fmt.Errorf("duration overflow: -(%s)", d)
+				}
+				d = -d
+			}
+			return DurationValue(d), nil
+		}
+		if q, ok := operand.quantity(); ok {
+			if op == "-" {
+				q = QuantityValue(new(big.Rat).Neg(q.d), q.s)
+			}
+			return q, nil
+		}
+		n, ok := operand.numeric()
+		if !ok {
+			return Value{}, fmt.Errorf("type error: cannot apply unary %s to %s", op, operand.describe())
+		}
+		if op == "+" {
+			return n, nil
+		}
+		if n.Kind() == KindInt {
+			if n.i == math.MinInt64 {
+				return DecimalValue(new(big.Rat).Neg(n.rat())), nil
+			}
+			return IntValue(-n.i), nil
+		}
+		if n.Kind() == KindDecimal {
+			return DecimalValue(new(big.Rat).Neg(n.d)), nil
+		}
+		return FloatValue(-n.f), nil
+	case "~":
+		i, err := operand.toInt64()
+		if err != nil {
+			return Value{}, fmt.Errorf("type error: cannot apply ~ to %s", operand.describe())
+		}
+		return IntValue(^i), nil
+	default:
+		return Value{}, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// binaryOp applies an arithmetic or comparison operator to two values.
+// Arithmetic is decimal if mode is set or an operand is a decimal.
+func binaryOp(mode *DecimalMode, op string, left, right Value) (Value, error) {
+	switch op {
+	case "==":
+		return BoolValue(equal(left, right)), nil
+	case "!=":
+		return BoolValue(!equal(left, right)), nil
+	case "<", "<=", ">", ">=":
+		return compare(op, left, right)
+	case "&", "|", "^", "<<", ">>":
+		return bitwiseOp(op, left, right)
+	}
+
+	if err := checkAmbiguous(left, right); err != nil {
+		return Value{}, err
+	}
+	if result, ok, err := quantityOp(op, left, right); ok {
+		return result, err
+	}
+	if result, ok, err := durationOp(op, left, right); ok {
+		return result, err
+	}
+
+	l, lok := left.numeric()
+	r, rok := right.numeric()
+	if !lok || !rok {
+		return Value{}, typeError(op, left, right)
+	}
+
+	decimal := mode != nil || l.Kind() == KindDecimal || r.Kind() == KindDecimal
+	if l.Kind() == KindInt && r.Kind() == KindInt {
+		result, ok, err := intOp(op, l.i, r.i)
+		if ok || (err != nil && !errors.Is(err, errIntOverflow)) {
+			return result, err
+		}
+		// Results beyond int64 continue exactly in arbitrary precision
+		decimal = decimal || err != nil
+	}
+	if decimal {
+		if mode == nil {
+			mode = &DefaultDecimalMode
+		}
+		return decimalOp(*mode, op, l, r)
+	}
+
+	result, err := applyOp(op, l.Float(), r.Float())
+	if err != nil {
+		return Value{}, err
+	}
+	return FloatValue(result), nil
+}
+
+// bitwiseOp applies a bitwise operator to two integers. Numbers with a
+// fractional part are a type error.
+func bitwiseOp(op string, left, right Value) (Value, error) {
+	l, lerr := left.toInt64()
+	r, rerr := right.toInt64()
+	if lerr != nil || rerr != nil {
+		return Value{}, typeError(op, left, right)
+	}
+
+	switch op {
+	case "&":
+		return IntValue(l & r), nil
+	case "|":
+		return IntValue(l | r), nil
+	case "^":
+		return IntValue(l ^ r), nil
+	}
+
+	if r < 0 {
+		return Value{}, fmt.Errorf("negative shift count: %d", r)
+	}
+	if op == ">>" {
+		return IntValue(l >> min(r, 63)), nil
+	}
+	if r > 63 || (l<<r)>>r != l {
+		if l == 0 {
+			return IntValue(0), nil
+		}
+		return Value{}, fmt.Errorf("integer overflow: %d << %d", l, r)
+	}
+	return IntValue(l << r), nil
+}
+
+// applyOp applies an arithmetic operator to two numbers
+func applyOp(op string, left, right float64) (float64, error) {
+	switch op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case "//":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Floor(left / right), nil
+	case "%":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left - right*math.Floor(left/right), nil
+	case "**":
+		result := math.Pow(left, right)
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return 0, fmt.Errorf("invalid power: %s ** %s", formatFloat(left), formatFloat(right))
+		}
+		return result, nil
+	default:
+		return 0, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// intOp applies an arithmetic operator to two integers with exact results.
+// Overflow is an errIntOverflow error; ok is false if the result needs a float, e.g. for
+// division with a remainder or a negative exponent.
+func intOp(op string, l, r int64) (result Value, ok bool, err error) {
+	var i int64
+	switch op {
+	case "+":
+		i, ok = addInt(l, r)
+	case "-":
+		i, ok = subInt(l, r)
+	case "*":
+		i, ok = mulInt(l, r)
+	case "/", "//", "%":
+		if r == 0 {
+			return Value{}, false, fmt.Errorf("division by zero")
+		}
+		if l == math.MinInt64 && r == -1 && op != "%" {
+			ok = false
+			break
+		}
+		q, m := floorDivMod(l, r)
+		switch {
+		case op == "%":
+			return IntValue(m), true, nil
+		case op == "//" || m == 0:
+			return IntValue(q), true, nil
+		}
+		return Value{}, false, nil
+	case "**":
+		if r < 0 {
+			return Value{}, false, nil
+		}
+		i, ok = powInt(l, r)
+	default:
+		return Value{}, false, nil
+	}
+	if !ok {
+		return Value{}, false, fmt.Errorf("%w: %d %s %d", errIntOverflow, l, op, r)
+	}
+	return IntValue(i), true, nil
+}
+
+// addInt returns a + b and whether it did not overflow
+func addInt(a, b int64) (int64, bool) {
+	s := a + b
+	return s, (s > a) == (b > 0)
+}
+
+// subInt returns a - b and whether it did not overflow
+func subInt(a, b int64) (int64, bool) {
+	d := a - b
+	return d, (d < a) == (b > 0)
+}
+
+// mulInt returns a * b and whether it did not overflow
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	p := a * b
+	if p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return p, true
+}
+
+// floorDivMod returns the quotient rounded towards negative infinity and the
+// matching remainder, which has the sign of the divisor (-7 // 2 is -4, -7 % 2 is 1)
+func floorDivMod(a, b int64) (int64, int64) {
+	q, m := a/b, a%b
+	if m != 0 && (m < 0) != (b < 0) {
+		q--
+		m += b
+	}
+	return q, m
+}
+
+// powInt raises base to a non-negative integer exponent and reports whether
+// the result did not overflow
+func powInt(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// equal compares two values. Numbers (including numeric strings) compare by
+// value; other values are equal if they have the same kind and content.
+func equal(left, right Value) bool {
+	if left.Kind() == KindTime && right.Kind() == KindTime {
+		return left.t.Equal(right.t)
+	}
+	if l, ok := left.quantity(); ok {
+		if r, ok := right.quantity(); ok {
+			return l.d.Cmp(r.d) == 0
+		}
+	}
+	if l, ok := left.duration(); ok {
+		if r, ok := right.duration(); ok {
+			return l == r
+		}
+	}
+	if l, ok := left.numeric(); ok {
+		if r, ok := right.numeric(); ok {
+			return compareNumbers(l, r) == 0
+		}
+	}
+	if left.Kind() != right.Kind() {
+		return false
+	}
+	return reflect.DeepEqual(left.Interface(), right.Interface())
+}
+
+// compare orders two numbers, two quantities, two durations, two times, or
+// two strings lexicographically
+func compare(op string, left, right Value) (Value, error) {
+	var c int
+	l, lok := left.numeric()
+	r, rok := right.numeric()
+	lq, lqok := left.quantity()
+	rq, rqok := right.quantity()
+	ld, ldok := left.duration()
+	rd, rdok := right.duration()
+	switch {
+	case lqok && rqok:
+		c = lq.d.Cmp(rq.d)
+	case left.Kind() == KindTime && right.Kind() == KindTime:
+		c = left.t.Compare(right.t)
+	case ldok && rdok:
+		c = cmp.Compare(ld, rd)
+	case lok && rok:
+		c = compareNumbers(l, r)
+	case left.Kind() == KindString && right.Kind() == KindString:
+		c = strings.Compare(left.s, right.s)
+	default:
+		return Value{}, typeError(op, left, right)
+	}
+
+	switch op {
+	case "<":
+		return BoolValue(c < 0), nil
+	case "<=":
+		return BoolValue(c <= 0), nil
+	case ">":
+		return BoolValue(c > 0), nil
+	default:
+		return BoolValue(c >= 0), nil
+	}
+}
+
+// compareNumbers returns -1, 0 or 1 as l is less than, equal to or greater than r
+func compareNumbers(l, r Value) int {
+	if l.Kind() == KindInt && r.Kind() == KindInt {
+		return cmp.Compare(l.i, r.i)
+	}
+	if l.Kind() == KindDecimal || r.Kind() == KindDecimal {
+		return l.rat().Cmp(r.rat())
+	}
+	return cmp.Compare(l.Float(), r.Float())
+}
@@ -0,0 +1,92 @@
+package expr
+
+import "fmt"
+
+// filter is a filter-only built-in. Filters that are not registered here
+// fall back to the built-in function of the same name, which receives the
+// piped value as its first argument.
+type filter struct {
+	minArgs, maxArgs int
+	// acceptsMissing filters also run when the input could not be resolved
+	acceptsMissing bool
+	apply          func(input Value, args []Value) (Value, error)
+}
+
+// filters is the registry of filter-only built-ins, keyed by name
+var filters = map[string]filter{
+	"default": {
+		minArgs:        1,
+		maxArgs:        1,
+		acceptsMissing: true,
+		apply: func(input Value, args []Value) (Value, error) {
+			if input.IsNull() || (input.Kind() == KindString && input.String() == "") {
+				return args[0], nil
+			}
+			return input, nil
+		},
+	},
+}
+
+// checkFilter validates a filter name and its argument count
+func checkFilter(name string, nargs int) error {
+	f, ok := filters[name]
+	if !ok {
+		minArgs, maxArgs, ok := lookupFunction(name)
+		if !ok {
+			return fmt.Errorf("unknown filter: %s", name)
+		}
+		// The piped value is the function's first argument
+		f = filter{minArgs: max(minArgs-1, 0), maxArgs: maxArgs}
+		if maxArgs != variadic {
+			f.maxArgs = maxArgs - 1
+		}
+	}
+	if !argsInRange(nargs, f.minArgs, f.maxArgs) {
+		return fmt.Errorf("filter %s expects %s, got %d", name, argCount(f.minArgs, f.maxArgs), nargs)
+	}
+	return nil
+}
+
+// argsInRange reports whether nargs is an accepted argument count
+func argsInRange(nargs, minArgs, maxArgs int) bool {
+	return nargs >= minArgs && (maxArgs == variadic || nargs <= maxArgs)
+}
+
+// argCount describes an expected argument count for error messages
+func argCount(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs == variadic && minArgs == 1:
+		return "at least 1 argument"
+	case maxArgs == variadic:
+		return fmt.Sprintf("at least %d arguments", minArgs)
+	case minArgs == maxArgs && minArgs == 1:
+		return "1 argument"
+	case minArgs == maxArgs:
+		return fmt.Sprintf("%d arguments", minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", minArgs, maxArgs)
+	}
+}
+
+// ApplyFilter applies the named built-in filter to input with the given arguments.
+// A null input means the placeholder's expression could not be resolved (or is
+// null); only filters such as default accept it.
+func ApplyFilter(name string, input Value, args []Value) (Value, error) {
+	return (&Evaluator{}).ApplyFilter(name, input, args)
+}
+
+// ApplyFilter is like the package-level ApplyFilter, using e's clock and
+// random generator
+func (e *Evaluator) ApplyFilter(name string, input Value, args []Value) (Value, error) {
+	if err := checkFilter(name, len(args)); err != nil {
+		return Value{}, err
+	}
+	f, ok := filters[name]
+	if input.IsNull() && !f.acceptsMissing {
+		return Value{}, fmt.Errorf("filter %s: missing input value", name)
+	}
+	if !ok {
+		return e.callFunction(name, append([]Value{input}, args...))
+	}
+	return f.apply(input, args)
+}
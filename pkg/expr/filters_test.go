@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+// valuesOf converts plain Go values to Values
+func valuesOf(values []interface{}) []Value {
+	result := make([]Value, len(values))
+	for i, v := range values {
+		result[i] = ValueOf(v)
+	}
+	return result
+}
+
+func TestApplyFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		input  interface{}
+		args   []interface{}
+		want   interface{}
+	}{
+		{"upper", "upper", "hello", nil, "HELLO"},
+		{"lower", "lower", "HeLLo", nil, "hello"},
+		{"trim", "trim", "  padded \n", nil, "padded"},
+		{"upper number", "upper", 42, nil, "42"},
+		{"default missing", "default", nil, []interface{}{"localhost"}, "localhost"},
+		{"default empty", "default", "", []interface{}{"localhost"}, "localhost"},
+		{"default present", "default", "db", []interface{}{"localhost"}, "db"},
+		{"default keeps zero", "default", 0, []interface{}{5.0}, 0},
+		{"default null", "default", nil, []interface{}{5.0}, 5.0},
+		{"join", "join", []interface{}{"a", "b", 3}, []interface{}{","}, "a,b,3"},
+		{"join empty", "join", []interface{}{}, []interface{}{","}, ""},
+		{"replace", "replace", "a-b-c", []interface{}{"-", "_"}, "a_b_c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyFilter(tt.filter, ValueOf(tt.input), valuesOf(tt.args))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := ValueOf(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyFilter_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		input  interface{}
+		args   []interface{}
+		want   string
+	}{
+		{"unknown filter", "shout", "x", nil, "unknown filter: shout"},
+		{"wrong arg count", "join", []interface{}{}, nil, "filter join expects 1 argument, got 0"},
+		{"missing input", "upper", nil, nil, "filter upper: missing input value"},
+		{"join non-list", "join", "abc", []interface{}{","}, `join: type error: expected a list, got string "abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyFilter(tt.filter, ValueOf(tt.input), valuesOf(tt.args))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
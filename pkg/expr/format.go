@@ -0,0 +1,209 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// formatSpec is a parsed format string: text around exactly one verb
+type formatSpec struct {
+	prefix, suffix string
+	flags          string // fmt flags without ','
+	width          string
+	precision      string // including the leading '.'
+	verb           byte
+	group          bool // ',' flag: thousands separators
+}
+
+// parseFormat parses a printf-style format string with exactly one verb.
+// %% is a literal percent sign.
+func parseFormat(format string) (formatSpec, error) {
+	var spec formatSpec
+	var text strings.Builder
+	found := false
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			text.WriteByte(format[i])
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			text.WriteByte('%')
+			i++
+			continue
+		}
+		if found {
+			return formatSpec{}, fmt.Errorf("format %q has more than one verb", format)
+		}
+		found = true
+		spec.prefix = text.String()
+		text.Reset()
+
+		i++
+		for ; i < len(format) && strings.IndexByte("-+# 0,", format[i]) >= 0; i++ {
+			if format[i] == ',' {
+				spec.group = true
+			} else {
+				spec.flags += string(format[i])
+			}
+		}
+		start := i
+		for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+		}
+		spec.width = format[start:i]
+		if i < len(format) && format[i] == '.' {
+			start = i
+			for i++; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+			}
+			spec.precision = format[start:i]
+		}
+		if i >= len(format) {
+			return formatSpec{}, fmt.Errorf("format %q ends before the verb", format)
+		}
+		spec.verb = format[i]
+		if strings.IndexByte("dxXobfFeEgGpsvqt", spec.verb) < 0 {
+			return formatSpec{}, fmt.Errorf("unsupported format verb %%%c", spec.verb)
+		}
+		if spec.group && strings.IndexByte("dfFp", spec.verb) < 0 {
+			return formatSpec{}, fmt.Errorf("flag ',' is not supported with %%%c", spec.verb)
+		}
+	}
+	if !found {
+		return formatSpec{}, fmt.Errorf("format %q has no verb", format)
+	}
+	spec.suffix = text.String()
+	return spec, nil
+}
+
+// CheckFormat validates a format string for Format
+func CheckFormat(format string) error {
+	_, err := parseFormat(format)
+	return err
+}
+
+// Format formats a value with a printf-style format string containing exactly
+// one verb. Supported verbs: %d, %x, %X, %o, %b (integers), %f, %F, %e, %E, %g,
+// %G (numbers), %p (number as percentage: 0.125 -> 12.5%; without precision
+// as many digits as needed), %s, %v, %q (text) and %t (bools). The ',' flag adds thousands separators to %d, %f and %p.
+func Format(v Value, format string) (string, error) {
+	spec, err := parseFormat(format)
+	if err != nil {
+		return "", err
+	}
+
+	var s string
+	switch spec.verb {
+	case 'd', 'x', 'X', 'o', 'b':
+		i, err := v.toInt64()
+		if err != nil {
+			return "", fmt.Errorf("format %%%c: %w", spec.verb, err)
+		}
+		s = spec.sprintf(spec.verb, i)
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		n, ok := v.numeric()
+		if !ok {
+			return "", fmt.Errorf("format %%%c: type error: expected a number, got %s", spec.verb, v.describe())
+		}
+		s = spec.sprintf(spec.verb, n.formatOperand())
+	case 'p':
+		n, ok := v.numeric()
+		if !ok {
+			return "", fmt.Errorf("format %%p: type error: expected a number, got %s", v.describe())
+		}
+		if n.Kind() == KindFloat && (math.IsInf(n.f, 0) || math.IsNaN(n.f)) {
+			return "", fmt.Errorf("format %%p: invalid number %s", n)
+		}
+		percent := new(big.Rat).Mul(n.rat(), big.NewRat(100, 1))
+		if spec.precision == "" {
+			// Like unformatted numbers, the shortest exact form
+			spec.precision = "." + strconv.Itoa(fractionDigits(percent))
+		}
+		s = spec.sprintf('f', new(big.Float).SetPrec(256).SetRat(percent)) + "%"
+	case 't':
+		b, err := v.truth()
+		if err != nil {
+			return "", fmt.Errorf("format %%t: %w", err)
+		}
+		s = spec.sprintf('t', b)
+	default:
+		s = spec.sprintf(spec.verb, v.String())
+	}
+	return spec.prefix + s + spec.suffix, nil
+}
+
+// sprintf formats arg with the spec's flags, width and precision and the given
+// verb. With the ',' flag, width is applied after grouping.
+func (spec formatSpec) sprintf(verb byte, arg interface{}) string {
+	if !spec.group {
+		return fmt.Sprintf("%"+spec.flags+spec.width+spec.precision+string(verb), arg)
+	}
+	s := groupThousands(fmt.Sprintf("%"+strings.ReplaceAll(spec.flags, "-", "")+spec.precision+string(verb), arg))
+	width, err := strconv.Atoi(spec.width)
+	if err != nil {
+		// No width
+		return s
+	}
+	if strings.Contains(spec.flags, "-") {
+		return fmt.Sprintf("%-*s", width, s)
+	}
+	return fmt.Sprintf("%*s", width, s)
+}
+
+// groupThousands inserts ',' between groups of three digits in the integer
+// part of a formatted number
+func groupThousands(s string) string {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return s
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	digits := s[start:end]
+	var sb strings.Builder
+	sb.WriteString(s[:start])
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	sb.WriteString(s[end:])
+	return sb.String()
+}
+
+// formatOperand returns a number as an argument for fmt's floating-point verbs.
+// Decimals are passed as big.Float so that no digits are lost.
+func (v Value) formatOperand() interface{} {
+	switch v.kind {
+	case KindInt:
+		return float64(v.i)
+	case KindDecimal:
+		return new(big.Float).SetPrec(256).SetRat(v.d)
+	default:
+		return v.f
+	}
+}
+
+// toInt64 converts a numeric value without fractional part to int64
+func (v Value) toInt64() (int64, error) {
+	n, ok := v.numeric()
+	if ok {
+		switch n.kind {
+		case KindInt:
+			return n.i, nil
+		case KindFloat:
+			if n.f == math.Trunc(n.f) && n.f >= math.MinInt64 && n.f < math.MaxInt64 {
+				return int64(n.f), nil
+			}
+		case KindDecimal:
+			if n.d.IsInt() && n.d.Num().IsInt64() {
+				return n.d.Num().Int64(), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("type error: expected an integer, got %s", v.describe())
+}
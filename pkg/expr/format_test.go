@@ -0,0 +1,129 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  Value
+		format string
+		want   string
+	}{
+		{"fixed precision", FloatValue(22.988499999999995), "%.2f", "22.99"},
+		{"zero padded", FloatValue(3.14159), "%08.3f", "0003.142"},
+		{"int as float", IntValue(5), "%.2f", "5.00"},
+		{"decimal", DecimalValue(big.NewRat(229885, 10000)), "%.3f", "22.989"},
+		{"decimal digits beyond float64", DecimalValue(new(big.Rat).SetFrac64(1, 3)), "%.20f", "0.33333333333333333333"},
+		{"surrounding text", FloatValue(9.5), "%.2f EUR", "9.50 EUR"},
+		{"escaped percent", IntValue(50), "%d%%", "50%"},
+		{"integer", IntValue(42), "%5d", "   42"},
+		{"integral float as integer", FloatValue(42), "%d", "42"},
+		{"numeric string", StringValue("8080"), "%d", "8080"},
+		{"hex", IntValue(255), "%x", "ff"},
+		{"upper hex with prefix", IntValue(255), "%#X", "0XFF"},
+		{"octal file mode", IntValue(493), "%#o", "0755"},
+		{"octal zero padded", IntValue(420), "%04o", "0644"},
+		{"binary", IntValue(10), "%b", "1010"},
+		{"scientific", FloatValue(1234.5), "%.2e", "1.23e+03"},
+		{"percent", FloatValue(0.125), "%.1p", "12.5%"},
+		{"percent of decimal", DecimalValue(big.NewRat(1, 3)), "%.2p", "33.33%"},
+		{"percent shortest", FloatValue(0.125), "%p", "12.5%"},
+		{"percent shortest float", FloatValue(0.07), "%p", "7%"},
+		{"percent shortest int", IntValue(2), "%,p", "200%"},
+		{"percent shortest with width", FloatValue(0.5), "%6p", "    50%"},
+		{"thousands", IntValue(1234567), "%,d", "1,234,567"},
+		{"thousands negative", IntValue(-1234567), "%,d", "-1,234,567"},
+		{"thousands small", IntValue(999), "%,d", "999"},
+		{"thousands float", FloatValue(1234567.891), "%,.2f", "1,234,567.89"},
+		{"thousands with width", IntValue(1234), "%,8d", "   1,234"},
+		{"thousands left-aligned", IntValue(1234), "%-,8d|", "1,234   |"},
+		{"thousands percent", FloatValue(12.5), "%,.0p", "1,250%"},
+		{"string", StringValue("api"), "%-5s|", "api  |"},
+		{"quoted", StringValue("a b"), "%q", `"a b"`},
+		{"value", ListValue([]Value{IntValue(1)}), "%v", "[1]"},
+		{"bool", BoolValue(true), "%t", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.value, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  Value
+		format string
+		want   string
+	}{
+		{"no verb", IntValue(1), "abc", `format "abc" has no verb`},
+		{"two verbs", IntValue(1), "%d %d", `format "%d %d" has more than one verb`},
+		{"missing verb", IntValue(1), "%.2", `format "%.2" ends before the verb`},
+		{"unsupported verb", IntValue(1), "%z", "unsupported format verb %z"},
+		{"grouping hex", IntValue(1), "%,x", "flag ',' is not supported with %x"},
+		{"fraction as integer", FloatValue(1.5), "%d", "format %d: type error: expected an integer, got float 1.5"},
+		{"string as number", StringValue("abc"), "%.2f", `format %f: type error: expected a number, got string "abc"`},
+		{"string as bool", StringValue("yes"), "%t", `format %t: type error: expected a bool, got string "yes"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Format(tt.value, tt.format)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaceholder_Format(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		want   string
+	}{
+		{".price * 1.15 :%.2f", "%.2f", "(.price * 1.15) :%.2f"},
+		{".price :  %.2f EUR ", "%.2f EUR ", ".price :%.2f EUR "},
+		{`.price | default 0 :%,d`, "%,d", ".price | default 0 :%,d"},
+		{`.a ? 1 : 2 :%d`, "%d", "(.a ? 1 : 2) :%d"},
+		{".mode", "", ".mode"},
+	}
+
+	for _, tt := range tests {
+		ph, err := ParsePlaceholder(tt.input)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if ph.Format != tt.format || ph.String() != tt.want {
+			t.Errorf("%s: got format %q, string %q, want %q, %q", tt.input, ph.Format, ph.String(), tt.format, tt.want)
+		}
+	}
+
+	if _, err := ParsePlaceholder(".price :%y"); err == nil || err.Error() != "unsupported format verb %y" {
+		t.Errorf("got error %v, want unsupported format verb", err)
+	}
+}
+
+func TestEvalValue_FormatFunction(t *testing.T) {
+	got, err := ParseAndEvalValue(`format(1234.5, "%,.2f")`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Kind() != KindString || got.String() != "1,234.50" {
+		t.Errorf("got %s, want string 1,234.50", got.describe())
+	}
+}
@@ -0,0 +1,257 @@
+package expr
+
+import (
+	"crypto/md5"  // #nosec G501 -- md5() is a checksum function, not used for security
+	"crypto/sha1" // #nosec G505 -- sha1() is a checksum function, not used for security
+	"crypto/sha256"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// function is a built-in function callable as name(args...) in expressions.
+// Every function can also be used as a filter, receiving the piped value as
+// its first argument.
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []Value) (Value, error)
+}
+
+// variadic is the maxArgs of functions accepting any number of arguments
+const variadic = -1
+
+// functions is the registry of built-in functions, keyed by name
+var functions = map[string]function{
+	"upper": {1, 1, func(args []Value) (Value, error) {
+		return StringValue(strings.ToUpper(args[0].String())), nil
+	}},
+	"lower": {1, 1, func(args []Value) (Value, error) {
+		return StringValue(strings.ToLower(args[0].String())), nil
+	}},
+	"trim": {1, 1, func(args []Value) (Value, error) {
+		return StringValue(strings.TrimSpace(args[0].String())), nil
+	}},
+	"replace": {3, 3, func(args []Value) (Value, error) {
+		return StringValue(strings.ReplaceAll(args[0].String(), args[1].String(), args[2].String())), nil
+	}},
+	"substr":      {2, 3, substr},
+	"pad_left":    {2, 3, padLeft},
+	"split":       {2, 2, split},
+	"join":        {2, 2, join},
+	"contains":    {2, 2, contains},
+	"starts_with": {2, 2, startsWith},
+	"ends_with": {2, 2, func(args []Value) (Value, error) {
+		return BoolValue(strings.HasSuffix(args[0].String(), args[1].String())), nil
+	}},
+	"min":   {1, variadic, minValue},
+	"max":   {1, variadic, maxValue},
+	"abs":   {1, 1, abs},
+	"round": {1, 2, round},
+	"floor": {1, 1, toInteger(math.Floor, ratFloor)},
+	"ceil":  {1, 1, toInteger(math.Ceil, ratCeil)},
+	"sqrt":  {1, 1, sqrt},
+	"pow": {2, 2, func(args []Value) (Value, error) {
+		return binaryOp(nil, "**", args[0], args[1])
+	}},
+	"log":          {1, 2, logarithm},
+	"clamp":        {3, 3, clamp},
+	"duration":     {1, 1, toDuration},
+	"quantity":     {1, 1, toQuantity},
+	"seconds":      {1, 1, durationUnit(time.Second)},
+	"millis":       {1, 1, durationUnit(time.Millisecond)},
+	"bytes":        {1, 1, quantityBytes},
+	"to_unit":      {2, 2, toUnit},
+	"parse_time":   {1, 2, parseTime},
+	"add_duration": {2, 2, addDuration},
+	"in_tz":        {2, 2, inTZ},
+	"semver_major": {1, 1, semverPart(func(v semver) int64 { return v.major })},
+	"semver_minor": {1, 1, semverPart(func(v semver) int64 { return v.minor })},
+	"semver_patch": {1, 1, semverPart(func(v semver) int64 { return v.patch })},
+	"semver_prerelease": {1, 1, semverField(func(v semver) Value {
+		return StringValue(v.prerelease)
+	})},
+	"semver_build": {1, 1, semverField(func(v semver) Value {
+		return StringValue(v.build)
+	})},
+	"semver_bump_major": {1, 1, semverBump(bumpMajor)},
+	"semver_bump_minor": {1, 1, semverBump(bumpMinor)},
+	"semver_bump_patch": {1, 1, semverBump(bumpPatch)},
+	"semver_compare":    {2, 2, semverCompare},
+	"semver_valid":      {1, 1, semverValid},
+	"cidrhost":          {2, 2, cidrHost},
+	"cidrsubnet":        {3, 3, cidrSubnet},
+	"cidrnetmask":       {1, 1, cidrNetmask},
+	"ip_add":            {2, 2, ipAdd},
+	"b64enc":            {1, 1, stringFunction(b64enc)},
+	"b64dec":            {1, 1, b64dec},
+	"hex":               {1, 1, stringFunction(hexEncode)},
+	"urlquery":          {1, 1, stringFunction(urlQuery)},
+	"urlpath":           {1, 1, stringFunction(urlPath)},
+	"html_escape":       {1, 1, stringFunction(htmlEscape)},
+	"shell_quote":       {1, 1, stringFunction(shellQuote)},
+	"json_quote":        {1, 1, jsonQuote},
+	"yaml_quote":        {1, 1, yamlQuote},
+	"sha256":            {1, 1, hashFunction(sha256.New)},
+	"sha1":              {1, 1, hashFunction(sha1.New)}, // #nosec G401 -- checksum, not used for security
+	"md5":               {1, 1, hashFunction(md5.New)},  // #nosec G401 -- checksum, not used for security
+	"crc32":             {1, 1, hashFunction(crc32.NewIEEE)},
+	"fnv":               {1, 1, hashFunction(fnv.New64a)},
+	"uuid_v5":           {2, 2, uuidV5},
+	"format": {2, 2, func(args []Value) (Value, error) {
+		s, err := Format(args[0], args[1].String())
+		return StringValue(s), err
+	}},
+}
+
+// evaluatorFunction is a built-in function depending on the evaluator, such
+// as its clock or random generator
+type evaluatorFunction struct {
+	minArgs, maxArgs int
+	call             func(e *Evaluator, args []Value) (Value, error)
+}
+
+// evaluatorFunctions is the registry of built-in functions depending on the
+// evaluator, keyed by name
+var evaluatorFunctions = map[string]evaluatorFunction{
+	"now": {0, 0, func(e *Evaluator, args []Value) (Value, error) {
+		return TimeValue(e.now()), nil
+	}},
+	"date":          {1, 2, (*Evaluator).date},
+	"unix":          {0, 1, (*Evaluator).unix},
+	"random_string": {1, 3, (*Evaluator).randomString},
+	"random_int":    {2, 3, (*Evaluator).randomInt},
+	"uuid":          {0, 1, (*Evaluator).uuid},
+}
+
+// lookupFunction returns the argument counts of a built-in function
+func lookupFunction(name string) (minArgs, maxArgs int, ok bool) {
+	if f, ok := functions[name]; ok {
+		return f.minArgs, f.maxArgs, true
+	}
+	if f, ok := evaluatorFunctions[name]; ok {
+		return f.minArgs, f.maxArgs, true
+	}
+	return 0, 0, false
+}
+
+// checkFunction validates a function name and its argument count
+func checkFunction(name string, nargs int) error {
+	minArgs, maxArgs, ok := lookupFunction(name)
+	if !ok {
+		return fmt.Errorf("unknown function: %s", name)
+	}
+	if !argsInRange(nargs, minArgs, maxArgs) {
+		return fmt.Errorf("function %s expects %s, got %d", name, argCount(minArgs, maxArgs), nargs)
+	}
+	return nil
+}
+
+// callFunction calls the named built-in function with evaluated arguments
+func (e *Evaluator) callFunction(name string, args []Value) (Value, error) {
+	if err := checkFunction(name, len(args)); err != nil {
+		return Value{}, err
+	}
+	var result Value
+	var err error
+	if f, ok := evaluatorFunctions[name]; ok {
+		result, err = f.call(e, args)
+	} else {
+		result, err = functions[name].call(args)
+	}
+	if err != nil {
+		return Value{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
+
+// substr returns the characters of s from start, optionally limited to length.
+// Bounds beyond the end of the string are clamped.
+func substr(args []Value) (Value, error) {
+	runes := []rune(args[0].String())
+	start, err := args[1].toInt()
+	if err != nil {
+		return Value{}, err
+	}
+	if start < 0 {
+		return Value{}, fmt.Errorf("negative start: %d", start)
+	}
+	start = min(start, len(runes))
+
+	end := len(runes)
+	if len(args) == 3 {
+		length, err := args[2].toInt()
+		if err != nil {
+			return Value{}, err
+		}
+		if length < 0 {
+			return Value{}, fmt.Errorf("negative length: %d", length)
+		}
+		end = min(start+length, len(runes))
+	}
+	return StringValue(string(runes[start:end])), nil
+}
+
+// padLeft pads s on the left to width characters with pad (default space)
+func padLeft(args []Value) (Value, error) {
+	s := args[0].String()
+	width, err := args[1].toInt()
+	if err != nil {
+		return Value{}, err
+	}
+	pad := " "
+	if len(args) == 3 {
+		pad = args[2].String()
+		if utf8.RuneCountInString(pad) != 1 {
+			return Value{}, fmt.Errorf("pad must be a single character, got %q", pad)
+		}
+	}
+	if n := width - utf8.RuneCountInString(s); n > 0 {
+		return StringValue(strings.Repeat(pad, n) + s), nil
+	}
+	return StringValue(s), nil
+}
+
+// split splits s around each occurrence of sep into a list
+func split(args []Value) (Value, error) {
+	parts := strings.Split(args[0].String(), args[1].String())
+	items := make([]Value, len(parts))
+	for i, p := range parts {
+		items[i] = StringValue(p)
+	}
+	return ListValue(items), nil
+}
+
+// join concatenates the elements of a list with a separator
+func join(args []Value) (Value, error) {
+	if args[0].Kind() != KindList {
+		return Value{}, fmt.Errorf("type error: expected a list, got %s", args[0].describe())
+	}
+	parts := make([]string, len(args[0].List()))
+	for i, item := range args[0].List() {
+		parts[i] = item.String()
+	}
+	return StringValue(strings.Join(parts, args[1].String())), nil
+}
+
+// contains reports whether a string contains a substring, or a list contains an element
+func contains(args []Value) (Value, error) {
+	if args[0].Kind() == KindList {
+		needle := args[1].String()
+		for _, item := range args[0].List() {
+			if item.String() == needle {
+				return BoolValue(true), nil
+			}
+		}
+		return BoolValue(false), nil
+	}
+	return BoolValue(strings.Contains(args[0].String(), args[1].String())), nil
+}
+
+// startsWith reports whether a string begins with a prefix
+func startsWith(args []Value) (Value, error) {
+	return BoolValue(strings.HasPrefix(args[0].String(), args[1].String())), nil
+}
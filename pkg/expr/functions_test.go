@@ -0,0 +1,165 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Helper resolver returning typed values
+func valueResolver(values map[string]interface{}) ValueResolver {
+	return func(path string) (Value, error) {
+		if val, ok := values[path]; ok {
+			return ValueOf(val), nil
+		}
+		return Value{}, fmt.Errorf("reference %w: %s", ErrNotFound, path)
+	}
+}
+
+func TestParse_FunctionCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"single argument", "upper(.name)", "upper(.name)"},
+		{"string arguments", `replace(.s, "a", "b")`, `replace(.s, "a", "b")`},
+		{"nested call", "upper(trim(.name))", "upper(trim(.name))"},
+		{"expression argument", "substr(.s, .start + 1, 3)", "substr(.s, (.start + 1), 3)"},
+		{"call in arithmetic", `1 + substr("123", 0, 1)`, `(1 + substr("123", 0, 1))`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_FunctionCallErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unknown function", "shout(.name)", "unknown function: shout"},
+		{"too few arguments", "replace(.s, \"a\")", "function replace expects 3 arguments, got 2"},
+		{"too many arguments", "upper(.a, .b)", "function upper expects 1 argument, got 2"},
+		{"optional arguments", "substr(.s)", "function substr expects 2 to 3 arguments, got 1"},
+		{"missing paren", "upper .name", "unexpected token: upper"},
+		{"unclosed call", "upper(.name", "expected ')' after arguments of upper, got "},
+		{"trailing comma", "upper(.name,)", "unexpected token: )"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_StringFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".name": "Alice",
+		".s":    "banana",
+		".id":   "abcdef123",
+		".csv":  "a,b,c",
+		".tags": []interface{}{"web", "api"},
+		".num":  7,
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"upper", "upper(.name)", "ALICE"},
+		{"lower", "lower(.name)", "alice"},
+		{"trim", `trim("  x  ")`, "x"},
+		{"replace", `replace(.s, "a", "o")`, "bonono"},
+		{"substr start", "substr(.id, 6)", "123"},
+		{"substr length", "substr(.id, 0, 3)", "abc"},
+		{"substr clamped", "substr(.id, 5, 100)", "f123"},
+		{"substr past end", "substr(.id, 50)", ""},
+		{"substr unicode", `substr("héllo", 1, 2)`, "él"},
+		{"pad_left", "pad_left(.num, 3)", "  7"},
+		{"pad_left with char", `pad_left(.num, 3, "0")`, "007"},
+		{"pad_left no-op", `pad_left(.name, 2, "0")`, "Alice"},
+		{"split", `split(.csv, ",")`, []interface{}{"a", "b", "c"}},
+		{"join", `join(split(.csv, ","), "-")`, "a-b-c"},
+		{"contains string", `contains(.s, "nan")`, true},
+		{"contains list", `contains(.tags, "api")`, true},
+		{"contains list missing", `contains(.tags, "db")`, false},
+		{"starts_with", `starts_with(.id, "abc")`, true},
+		{"ends_with", `ends_with(.id, "abc")`, false},
+		{"string literal", `"plain"`, "plain"},
+		{"arithmetic on result", `substr(.id, 6) + 1`, 124},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			got, err := EvalValue(node, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := ValueOf(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_Errors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{".name": "Alice"})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"string in arithmetic", ".name + 1", `type error: cannot apply + to string "Alice" and int 1`},
+		{"negative substr start", "substr(.name, 0 - 1)", "substr: negative start: -1"},
+		{"fractional index", "substr(.name, 1.5)", "substr: type error: expected an integer, got float 1.5"},
+		{"bad pad", `pad_left(.name, 9, "ab")`, `pad_left: pad must be a single character, got "ab"`},
+		{"join non-list", `join(.name, ",")`, `join: type error: expected a list, got string "Alice"`},
+		{"missing reference", "upper(.missing)", "reference not found: .missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			_, err = EvalValue(node, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_RejectsFunctionCalls(t *testing.T) {
+	if _, err := ParseAndEval("upper(.name)", testResolver(nil)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
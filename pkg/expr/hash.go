@@ -0,0 +1,90 @@
+package expr
+
+import (
+	"crypto/sha1" // #nosec G505 -- uuid_v5 requires SHA-1 (RFC 4122), not used for security
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// canonical returns the bytes hashed for a value: strings and scalars as
+// their text, lists and maps as JSON with sorted keys, so that a subtree
+// hashes the same regardless of YAML formatting and key order
+func canonical(v Value) ([]byte, error) {
+	if v.Kind() != KindList && v.Kind() != KindMap {
+		return []byte(v.String()), nil
+	}
+	return json.Marshal(v.Interface())
+}
+
+// hashFunction returns a function hashing its argument with h, as lowercase hex
+func hashFunction[H hash.Hash](h func() H) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		b, err := canonical(args[0])
+		if err != nil {
+			return Value{}, err
+		}
+		sum := h()
+		sum.Write(b)
+		return StringValue(hex.EncodeToString(sum.Sum(nil))), nil
+	}
+}
+
+// uuidNamespaces are the predefined name spaces of RFC 4122
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// parseUUID parses a UUID in its canonical 8-4-4-4-12 hex form
+func parseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	digits := strings.ReplaceAll(s, "-", "")
+	if len(digits) != 32 {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// formatUUID formats a UUID in its canonical form
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x",
+		binary.BigEndian.Uint32(u[0:4]), u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// uuidV5 returns the name-based (SHA-1) UUID of a name in a namespace. The
+// namespace is a UUID or one of dns, url, oid and x500.
+func uuidV5(args []Value) (Value, error) {
+	namespace := strings.TrimSpace(args[0].String())
+	if u, ok := uuidNamespaces[strings.ToLower(namespace)]; ok {
+		namespace = u
+	}
+	ns, err := parseUUID(namespace)
+	if err != nil {
+		return Value{}, err
+	}
+	name, err := canonical(args[1])
+	if err != nil {
+		return Value{}, err
+	}
+
+	h := sha1.New() // #nosec G401 -- uuid_v5 requires SHA-1 (RFC 4122)
+	h.Write(ns[:])
+	h.Write(name)
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50 // version 5
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return StringValue(formatUUID(u)), nil
+}
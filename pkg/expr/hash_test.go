@@ -0,0 +1,66 @@
+package expr
+
+import "testing"
+
+func TestHashFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".word": "hello",
+		".port": 8080,
+		".config": map[string]interface{}{
+			"b": 1,
+			"a": []interface{}{1, "x"},
+		},
+		".reordered": map[interface{}]interface{}{
+			"a": []interface{}{1, "x"},
+			"b": 1,
+		},
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"sha256", "sha256(.word)", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"sha1", "sha1(.word)", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"md5", "md5(.word)", "5d41402abc4b2a76b9719d911017c592"},
+		{"crc32", "crc32(.word)", "3610a686"},
+		{"fnv", "fnv(.word)", "a430d84680aabd0b"},
+		{"number as text", "md5(.port)", "d4a973e303ec37692cc8923e3148eef7"},
+		{"subtree as canonical json", "sha256(.config) == sha256(\"{\\\"a\\\":[1,\\\"x\\\"],\\\"b\\\":1}\")", "true"},
+		{"subtree independent of key order", "sha256(.config) == sha256(.reordered)", "true"},
+		{"uuid_v5 dns", `uuid_v5("dns", "www.example.com")`, "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+		{"uuid_v5 namespace uuid", `uuid_v5("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "www.example.com")`, "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+		{"uuid_v5 url", `uuid_v5("URL", "https://example.com")`, "4fd35a71-71ef-5a55-a9d9-aa75c889a6d0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUUIDv5Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`uuid_v5("example", "x")`, `uuid_v5: invalid UUID "example"`},
+		{`uuid_v5("6ba7b810-9dad-11d1-80b4-00c04fd430cz", "x")`, `uuid_v5: invalid UUID "6ba7b810-9dad-11d1-80b4-00c04fd430cz"`},
+		{`uuid_v5("6ba7b810-9dad-11d1-80b4-00c04fd4-0-8", "x")`, `uuid_v5: invalid UUID "6ba7b810-9dad-11d1-80b4-00c04fd4-0-8"`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseAndEvalValue(tt.input, valueResolver(nil))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
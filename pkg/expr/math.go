@@ -0,0 +1,207 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// numericArgs converts function arguments to numbers. A single list argument
+// is expanded, so min and max work on both min(a, b) and min(.list).
+func numericArgs(args []Value) ([]Value, error) {
+	if len(args) == 1 && args[0].Kind() == KindList {
+		args = args[0].List()
+		if len(args) == 0 {
+			return nil, fmt.Errorf("empty list")
+		}
+	}
+	numbers := make([]Value, len(args))
+	for i, arg := range args {
+		n, err := number(arg)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// minValue returns the smallest argument, or the smallest element of a list
+func minValue(args []Value) (Value, error) {
+	return extreme(args, -1)
+}
+
+// maxValue returns the largest argument, or the largest element of a list
+func maxValue(args []Value) (Value, error) {
+	return extreme(args, 1)
+}
+
+// extreme returns the number n for which compareNumbers(n, other) == sign for all others
+func extreme(args []Value, sign int) (Value, error) {
+	numbers, err := numericArgs(args)
+	if err != nil {
+		return Value{}, err
+	}
+	result := numbers[0]
+	for _, n := range numbers[1:] {
+		if compareNumbers(n, result) == sign {
+			result = n
+		}
+	}
+	return result, nil
+}
+
+// abs returns the absolute value, keeping the kind of number
+func abs(args []Value) (Value, error) {
+	n, err := number(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	switch n.Kind() {
+	case KindInt:
+		if n.i == math.MinInt64 {
+			return DecimalValue(new(big.Rat).Abs(n.rat())), nil
+		}
+		return IntValue(abs64(n.i)), nil
+	case KindDecimal:
+		return DecimalValue(new(big.Rat).Abs(n.d)), nil
+	default:
+		return FloatValue(math.Abs(n.f)), nil
+	}
+}
+
+// round rounds to the nearest integer, or to digits decimal places, with
+// halves rounded away from zero
+func round(args []Value) (Value, error) {
+	if len(args) == 1 {
+		return toInteger(math.Round, func(r *big.Rat) *big.Rat {
+			return roundRat(r, 0, RoundHalfUp)
+		})(args)
+	}
+	n, err := number(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	digits, err := args[1].toInt()
+	if err != nil {
+		return Value{}, err
+	}
+	if n.Kind() == KindDecimal {
+		return DecimalValue(roundDigits(n.d, digits)), nil
+	}
+	f := n.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return FloatValue(f), nil
+	}
+	rounded, _ := roundDigits(new(big.Rat).SetFloat64(f), digits).Float64()
+	return FloatValue(rounded), nil
+}
+
+// roundDigits rounds r half away from zero to the given number of fractional
+// digits. Digits beyond r's exact fraction or integer part change nothing, so
+// they are cut off before scaling by 10^digits.
+func roundDigits(r *big.Rat, digits int) *big.Rat {
+	if digits >= fractionDigits(r) {
+		return r
+	}
+	if -digits > len(new(big.Int).Quo(r.Num(), r.Denom()).Text(10)) {
+		return new(big.Rat)
+	}
+	return roundRat(r, digits, RoundHalfUp)
+}
+
+// toInteger wraps a rounding function for floats and its equivalent for
+// decimals. Integers are returned unchanged and rounded values become
+// integers if they fit into int64.
+func toInteger(fn func(float64) float64, ratFn func(*big.Rat) *big.Rat) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		n, err := number(args[0])
+		if err != nil {
+			return Value{}, err
+		}
+		switch n.Kind() {
+		case KindInt:
+			return n, nil
+		case KindDecimal:
+			r := ratFn(n.d)
+			if r.Num().IsInt64() {
+				return IntValue(r.Num().Int64()), nil
+			}
+			return DecimalValue(r), nil
+		}
+		f := fn(n.f)
+		if f >= math.MinInt64 && f < math.MaxInt64 {
+			return IntValue(int64(f)), nil
+		}
+		return FloatValue(f), nil
+	}
+}
+
+// ratCeil returns the smallest integer greater than or equal to r
+func ratCeil(r *big.Rat) *big.Rat {
+	floor := ratFloor(new(big.Rat).Neg(r))
+	return floor.Neg(floor)
+}
+
+// number converts an argument to an int, float or decimal
+func number(arg Value) (Value, error) {
+	n, ok := arg.numeric()
+	if !ok {
+		return Value{}, fmt.Errorf("type error: expected a number, got %s", arg.describe())
+	}
+	return n, nil
+}
+
+// sqrt returns the square root of a non-negative number
+func sqrt(args []Value) (Value, error) {
+	x, err := args[0].toFloat()
+	if err != nil {
+		return Value{}, err
+	}
+	if x < 0 {
+		return Value{}, fmt.Errorf("negative argument: %s", formatFloat(x))
+	}
+	return FloatValue(math.Sqrt(x)), nil
+}
+
+// logarithm returns the natural logarithm, or the logarithm to the given base
+func logarithm(args []Value) (Value, error) {
+	x, err := args[0].toFloat()
+	if err != nil {
+		return Value{}, err
+	}
+	if x <= 0 {
+		return Value{}, fmt.Errorf("non-positive argument: %s", formatFloat(x))
+	}
+	if len(args) == 1 {
+		return FloatValue(math.Log(x)), nil
+	}
+	base, err := args[1].toFloat()
+	if err != nil {
+		return Value{}, err
+	}
+	if base <= 0 || base == 1 {
+		return Value{}, fmt.Errorf("invalid base: %s", formatFloat(base))
+	}
+	return FloatValue(math.Log(x) / math.Log(base)), nil
+}
+
+// clamp limits x to the range [lo, hi]
+func clamp(args []Value) (Value, error) {
+	numbers, err := numericArgs(args)
+	if err != nil {
+		return Value{}, err
+	}
+	x, lo, hi := numbers[0], numbers[1], numbers[2]
+	if compareNumbers(lo, hi) > 0 {
+		return Value{}, fmt.Errorf("lower bound %s is greater than upper bound %s", lo, hi)
+	}
+	switch {
+	case compareNumbers(x, lo) < 0:
+		return lo, nil
+	case compareNumbers(x, hi) > 0:
+		return hi, nil
+	default:
+		return x, nil
+	}
+}
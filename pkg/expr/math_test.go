@@ -0,0 +1,135 @@
+package expr
+
+import "testing"
+
+func TestEvalValue_MathFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".cpus":    4,
+		".memory":  "3000",
+		".ratio":   0.75,
+		".limits":  []interface{}{8, 2.5, 16},
+		".neg":     -7,
+		".negHalf": -2.5,
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"min", "min(.cpus, 2, 3)", IntValue(2)},
+		{"min keeps int", "min(.cpus, 10)", IntValue(4)},
+		{"max", "max(.cpus, .memory)", IntValue(3000)},
+		{"max of list", "max(.limits)", IntValue(16)},
+		{"min of list", "min(.limits)", FloatValue(2.5)},
+		{"single argument", "min(.cpus)", IntValue(4)},
+		{"abs int", "abs(.neg)", IntValue(7)},
+		{"abs float", "abs(.negHalf)", FloatValue(2.5)},
+		{"round", "round(.memory * .ratio)", IntValue(2250)},
+		{"round half away from zero", "round(.negHalf)", IntValue(-3)},
+		{"round digits", "round(2 / 3, 2)", FloatValue(0.67)},
+		{"round negative digits", "round(1234, -2)", FloatValue(1200)},
+		{"round many digits", "round(2.5, 400)", FloatValue(2.5)},
+		{"round many negative digits", "round(2.5, -400)", FloatValue(0)},
+		{"round huge digits", "round(2 / 3, 1000000000)", FloatValue(2.0 / 3)},
+		{"floor", "floor(.memory * .ratio / 1000)", IntValue(2)},
+		{"ceil", "ceil(.cpus / 3)", IntValue(2)},
+		{"floor int", "floor(.cpus)", IntValue(4)},
+		{"sqrt", "sqrt(16)", FloatValue(4)},
+		{"pow", "pow(.cpus, 2)", IntValue(16)},
+		{"log", "round(log(100, 10))", IntValue(2)},
+		{"natural log", "log(1)", FloatValue(0)},
+		{"clamp low", "clamp(.cpus * 2, 10, 100)", IntValue(10)},
+		{"clamp high", "clamp(.memory, 256, 2048)", IntValue(2048)},
+		{"clamp inside", "clamp(.cpus, 1, 8)", IntValue(4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_MathFunctionErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".name":  "api",
+		".empty": []interface{}{},
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"non-numeric", "abs(.name)", `abs: type error: expected a number, got string "api"`},
+		{"non-numeric min", "min(1, .name)", `min: type error: expected a number, got string "api"`},
+		{"empty list", "max(.empty)", "max: empty list"},
+		{"negative sqrt", "sqrt(-4)", "sqrt: negative argument: -4"},
+		{"log of zero", "log(0)", "log: non-positive argument: 0"},
+		{"log base one", "log(8, 1)", "log: invalid base: 1"},
+		{"clamp bounds", "clamp(5, 10, 1)", "clamp: lower bound 10 is greater than upper bound 1"},
+		{"round digits", "round(1.5, 0.5)", "round: type error: expected an integer, got float 0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_VariadicFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"min()", "function min expects at least 1 argument, got 0"},
+		{"clamp(1, 2)", "function clamp expects 3 arguments, got 2"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestApplyFilter_MathFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		input  Value
+		args   []Value
+		want   string
+	}{
+		{"variadic", "max", IntValue(3), []Value{IntValue(5), IntValue(4)}, "5"},
+		{"optional argument", "round", FloatValue(2.456), []Value{IntValue(1)}, "2.5"},
+		{"list input", "max", ListValue([]Value{IntValue(1), IntValue(9)}), nil, "9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyFilter(tt.filter, tt.input, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
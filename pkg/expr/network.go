@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// prefixArg parses a function argument as a CIDR prefix such as 10.0.0.0/16.
+// Host bits are cleared, as in Terraform.
+func prefixArg(arg Value) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(arg.String()))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR prefix %q", arg.String())
+	}
+	return p.Masked(), nil
+}
+
+// addrToInt converts an address to an integer
+func addrToInt(a netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(a.AsSlice())
+}
+
+// intToAddr converts an integer to an address with the bit length of like,
+// reporting false if it does not fit
+func intToAddr(n *big.Int, like netip.Addr) (netip.Addr, bool) {
+	if n.Sign() < 0 || n.BitLen() > like.BitLen() {
+		return netip.Addr{}, false
+	}
+	b := n.FillBytes(make([]byte, like.BitLen()/8))
+	a, _ := netip.AddrFromSlice(b)
+	return a.WithZone(like.Zone()), true
+}
+
+// cidrHost returns the address of host number n in a prefix. Negative
+// numbers count back from the end of the prefix.
+func cidrHost(args []Value) (Value, error) {
+	p, err := prefixArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	host, err := args[1].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+
+	size := new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+	n := big.NewInt(host)
+	if host < 0 {
+		n.Add(n, size)
+	}
+	if n.Sign() < 0 || n.Cmp(size) >= 0 {
+		return Value{}, fmt.Errorf("prefix of %d bits cannot accommodate a host numbered %d", p.Bits(), host)
+	}
+	a, _ := intToAddr(n.Add(n, addrToInt(p.Addr())), p.Addr())
+	return StringValue(a.String()), nil
+}
+
+// cidrSubnet returns subnet number netnum of a prefix extended by newbits
+func cidrSubnet(args []Value) (Value, error) {
+	p, err := prefixArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	newbits, err := args[1].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+	netnum, err := args[2].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+
+	bits := int64(p.Bits()) + newbits
+	if newbits < 0 || bits > int64(p.Addr().BitLen()) {
+		return Value{}, fmt.Errorf("insufficient address space to extend prefix of %d bits by %d", p.Bits(), newbits)
+	}
+	if netnum < 0 || (newbits < 63 && netnum >= 1<<newbits) {
+		return Value{}, fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %d", newbits, netnum)
+	}
+	n := new(big.Int).Lsh(big.NewInt(netnum), uint(int64(p.Addr().BitLen())-bits))
+	a, _ := intToAddr(n.Add(n, addrToInt(p.Addr())), p.Addr())
+	return StringValue(netip.PrefixFrom(a, int(bits)).String()), nil
+}
+
+// cidrNetmask returns the netmask of an IPv4 prefix, e.g. 255.255.255.0
+func cidrNetmask(args []Value) (Value, error) {
+	p, err := prefixArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.Addr().Is4() {
+		return Value{}, fmt.Errorf("only IPv4 prefixes have a netmask: %s", p)
+	}
+	mask := netip.PrefixFrom(netip.AddrFrom4([4]byte{255, 255, 255, 255}), p.Bits()).Masked()
+	return StringValue(mask.Addr().String()), nil
+}
+
+// ipAdd adds n, which may be negative, to an IP address
+func ipAdd(args []Value) (Value, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(args[0].String()))
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid IP address %q", args[0].String())
+	}
+	n, err := args[1].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+	sum, ok := intToAddr(new(big.Int).Add(addrToInt(a), big.NewInt(n)), a)
+	if !ok {
+		return Value{}, fmt.Errorf("address out of range: %s + %d", a, n)
+	}
+	return StringValue(sum.String()), nil
+}
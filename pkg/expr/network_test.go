@@ -0,0 +1,75 @@
+package expr
+
+import "testing"
+
+func TestNetworkFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".net":     "10.0.0.0/16",
+		".net6":    "fd00:1::/48",
+		".gateway": "10.0.0.1",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"cidrhost", "cidrhost(.net, 10)", "10.0.0.10"},
+		{"cidrhost across octets", "cidrhost(.net, 300)", "10.0.1.44"},
+		{"cidrhost from end", `cidrhost("10.0.1.0/24", -2)`, "10.0.1.254"},
+		{"cidrhost masks host bits", `cidrhost("10.0.1.7/24", 5)`, "10.0.1.5"},
+		{"cidrhost ipv6", "cidrhost(.net6, 255)", "fd00:1::ff"},
+		{"cidrsubnet", "cidrsubnet(.net, 8, 2)", "10.0.2.0/24"},
+		{"cidrsubnet small", "cidrsubnet(.net, 12, 33)", "10.0.2.16/28"},
+		{"cidrsubnet ipv6", "cidrsubnet(.net6, 16, 3)", "fd00:1:0:3::/64"},
+		{"nested", "cidrhost(cidrsubnet(.net, 8, 2), 1)", "10.0.2.1"},
+		{"cidrnetmask", "cidrnetmask(.net)", "255.255.0.0"},
+		{"cidrnetmask odd", `cidrnetmask("192.168.0.0/27")`, "255.255.255.224"},
+		{"ip_add", "ip_add(.gateway, 1)", "10.0.0.2"},
+		{"ip_add carry", `ip_add("10.0.0.255", 1)`, "10.0.1.0"},
+		{"ip_add negative", "ip_add(.gateway, -2)", "9.255.255.255"},
+		{"ip_add ipv6", `ip_add("fd00::ffff", 1)`, "fd00::1:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"invalid prefix", `cidrhost("10.0.0.0", 1)`, `cidrhost: invalid CIDR prefix "10.0.0.0"`},
+		{"host out of range", `cidrhost("10.0.0.0/24", 256)`, "cidrhost: prefix of 24 bits cannot accommodate a host numbered 256"},
+		{"negative host out of range", `cidrhost("10.0.0.0/24", -257)`, "cidrhost: prefix of 24 bits cannot accommodate a host numbered -257"},
+		{"fractional host", `cidrhost("10.0.0.0/24", 1.5)`, "cidrhost: type error: expected an integer, got float 1.5"},
+		{"too many new bits", `cidrsubnet("10.0.0.0/24", 9, 0)`, "cidrsubnet: insufficient address space to extend prefix of 24 bits by 9"},
+		{"netnum out of range", `cidrsubnet("10.0.0.0/16", 8, 256)`, "cidrsubnet: prefix extension of 8 does not accommodate a subnet numbered 256"},
+		{"netmask ipv6", `cidrnetmask("fd00::/64")`, "cidrnetmask: only IPv4 prefixes have a netmask: fd00::/64"},
+		{"invalid address", `ip_add("10.0.0", 1)`, `ip_add: invalid IP address "10.0.0"`},
+		{"address overflow", `ip_add("255.255.255.255", 1)`, "ip_add: address out of range: 255.255.255.255 + 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, valueResolver(nil))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package expr
+
+import (
+	"testing"
+)
+
+func TestParse_Operators(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"comparison", ".a > 1", "(.a > 1)"},
+		{"comparison binds looser than arithmetic", ".a + 1 <= .b * 2", "((.a + 1) <= (.b * 2))"},
+		{"equality binds looser than comparison", ".a < 1 == .b > 2", "((.a < 1) == (.b > 2))"},
+		{"and binds tighter than or", ".a || .b && .c", "(.a || (.b && .c))"},
+		{"not", "!.a && .b", "((!.a) && .b)"},
+		{"double not", "!!.a", "(!(!.a))"},
+		{"coalesce", `.a ?? .b ?? "x"`, `((.a ?? .b) ?? "x")`},
+		{"ternary", `.a > 1 ? "ha" : "single"`, `((.a > 1) ? "ha" : "single")`},
+		{"nested ternary", `.a ? 1 : .b ? 2 : 3`, `(.a ? 1 : (.b ? 2 : 3))`},
+		{"ternary in parens", `(.a ? 1 : 2) + 1`, `((.a ? 1 : 2) + 1)`},
+		{"boolean literal", ".a == false", "(.a == false)"},
+		{"null literal", ".a ?? null", "(.a ?? null)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_OperatorErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing colon", `.a ? 1`, "expected ':' in conditional expression, got "},
+		{"single equals", `.a = 1`, "unexpected character: ="},
+		{"missing operand", `.a <`, "unexpected token: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_Operators(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".replicas": 3,
+		".name":     "api",
+		".port":     "8080",
+		".enabled":  true,
+		".flag":     "false",
+		".empty":    nil,
+		".list":     []interface{}{1, "a"},
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"greater", ".replicas > 1", BoolValue(true)},
+		{"less or equal", ".replicas <= 2", BoolValue(false)},
+		{"int and float", ".replicas >= 3.0", BoolValue(true)},
+		{"numeric string", ".port == 8080", BoolValue(true)},
+		{"string equality", `.name == "api"`, BoolValue(true)},
+		{"string inequality", `.name != "web"`, BoolValue(true)},
+		{"string ordering", `.name < "b"`, BoolValue(true)},
+		{"different kinds", `.enabled == "yes"`, BoolValue(false)},
+		{"list equality", `.list == .list`, BoolValue(true)},
+		{"and", ".enabled && .replicas > 1", BoolValue(true)},
+		{"or", `.flag || .name == "api"`, BoolValue(true)},
+		{"not", "!.enabled", BoolValue(false)},
+		{"and short-circuits", ".flag && .missing", BoolValue(false)},
+		{"or short-circuits", ".enabled || .missing", BoolValue(true)},
+		{"ternary", `.replicas > 1 ? "ha" : "single"`, StringValue("ha")},
+		{"ternary skips other branch", `.enabled ? .name : .missing`, StringValue("api")},
+		{"coalesce missing", `.missing ?? "default"`, StringValue("default")},
+		{"coalesce null", `.empty ?? 1`, IntValue(1)},
+		{"coalesce present", `.name ?? "default"`, StringValue("api")},
+		{"coalesce chain", `.missing ?? .empty ?? .replicas`, IntValue(3)},
+		{"true literal", ".enabled == true", BoolValue(true)},
+		{"false literal", ".flag == false", BoolValue(false)},
+		{"not false", "!false && true", BoolValue(true)},
+		{"coalesce to false", ".missing ?? false", BoolValue(false)},
+		{"coalesce to null", ".missing ?? null", NullValue()},
+		{"null literal", "null", NullValue()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_OperatorErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".replicas": 3,
+		".name":     "api",
+		".list":     []interface{}{1},
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ordering mixed kinds", `.name < 1`, `type error: cannot apply < to string "api" and int 1`},
+		{"ordering lists", `.list > .list`, "type error: cannot apply > to list [1] and list [1]"},
+		{"non-bool condition", `.replicas ? 1 : 2`, "condition: type error: expected a bool, got int 3"},
+		{"non-bool and", `.name && .replicas`, `&&: type error: expected a bool, got string "api"`},
+		{"non-bool not", `!.replicas`, "!: type error: expected a bool, got int 3"},
+		{"missing in and", `.missing && .name`, "reference not found: .missing"},
+		{"coalesce keeps other errors", `(1 / 0) ?? 2`, "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_ArithmeticOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unary minus", "-.offset", "(-.offset)"},
+		{"unary minus operand", ".a * -1", "(.a * (-1))"},
+		{"unary plus", "+.a", "(+.a)"},
+		{"double minus", "--1", "(-(-1))"},
+		{"modulo", ".a % 3 + 1", "((.a % 3) + 1)"},
+		{"integer division", ".a // 2 * 3", "((.a // 2) * 3)"},
+		{"power binds tighter than multiply", "2 * 3 ** 2", "(2 * (3 ** 2))"},
+		{"power is right-associative", "2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"power binds tighter than unary minus", "-2 ** 2", "(-(2 ** 2))"},
+		{"negative exponent", "2 ** -1", "(2 ** (-1))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_ArithmeticOperators(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".seven":  7,
+		".two":    2,
+		".offset": 5,
+		".half":   0.5,
+		".port":   "8080",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"negate int", "-.offset", IntValue(-5)},
+		{"negate float", "-.half", FloatValue(-0.5)},
+		{"negate numeric string", "-.port", IntValue(-8080)},
+		{"unary plus", "+.port", IntValue(8080)},
+		{"multiply by negative", ".offset * -1", IntValue(-5)},
+		{"modulo", ".seven % .two", IntValue(1)},
+		{"modulo negative", "-.seven % .two", IntValue(1)},
+		{"modulo float", "7.5 % 2", FloatValue(1.5)},
+		{"integer division", ".seven // .two", IntValue(3)},
+		{"integer division negative", "-.seven // .two", IntValue(-4)},
+		{"integer division float", "7.5 // 2", FloatValue(3)},
+		{"power", ".two ** 10", IntValue(1024)},
+		{"int power", ".two ** .seven", IntValue(128)},
+		{"negative exponent", ".two ** -1", FloatValue(0.5)},
+		{"power right-associative", "2 ** 3 ** 2", IntValue(512)},
+		{"power before unary minus", "-2 ** 2", IntValue(-4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_ArithmeticErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".zero": 0,
+		".name": "api",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"modulo by zero", "7 % .zero", "division by zero"},
+		{"integer division by zero", "7 // 0", "division by zero"},
+		{"int modulo by zero", ".zero % .zero", "division by zero"},
+		{"negate string", "-.name", `type error: cannot apply unary - to string "api"`},
+		{"invalid power", "(-8) ** 0.5", "invalid power: -8 ** 0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_BitwiseOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"and binds like multiply", ".a + .b & 3", "(.a + (.b & 3))"},
+		{"shift binds like multiply", "1 << .n * 2", "((1 << .n) * 2)"},
+		{"xor binds like add", ".a ^ .b & .c", "(.a ^ (.b & .c))"},
+		{"or inside parentheses", "(.a | .b) & 4", "((.a | .b) & 4)"},
+		{"or in function argument", "max(.a | 1, 2)", "max((.a | 1), 2)"},
+		{"bitwise not", "~.mask & 7", "((~.mask) & 7)"},
+		{"shift right", ".flags >> 2", "(.flags >> 2)"},
+		{"shift before comparison", "1 << 2 < 5", "((1 << 2) < 5)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_BitwiseOperators(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".flags": 0b1011,
+		".mask":  0xff,
+		".port":  "8080",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"and", ".flags & 0b0110", IntValue(2)},
+		{"or", "(.flags | 0b0100)", IntValue(15)},
+		{"xor", ".flags ^ 0b1111", IntValue(4)},
+		{"not", "~0", IntValue(-1)},
+		{"not and", ".mask & ~0x0f", IntValue(0xf0)},
+		{"shift left", "1 << 10", IntValue(1024)},
+		{"shift right", ".port >> 4", IntValue(505)},
+		{"shift right negative", "-16 >> 2", IntValue(-4)},
+		{"shift right past width", "-1 >> 100", IntValue(-1)},
+		{"shift zero", "0 << 100", IntValue(0)},
+		{"integral float", "6.0 & 3", IntValue(2)},
+		{"test flag", ".flags & 8 != 0", BoolValue(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_BitwiseErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".name": "api",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"fractional operand", "1.5 & 1", "type error: cannot apply & to float 1.5 and int 1"},
+		{"string operand", "(.name | 1)", `type error: cannot apply | to string "api" and int 1`},
+		{"not fractional", "~0.5", "type error: cannot apply ~ to float 0.5"},
+		{"negative shift", "1 << -1", "negative shift count: -1"},
+		{"shift overflow", "1 << 63", "integer overflow: 1 << 63"},
+		{"shift past width", "1 << 64", "integer overflow: 1 << 64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
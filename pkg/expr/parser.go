@@ -1,8 +1,12 @@
 package expr
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
+	"time"
 	"unicode"
 )
 
@@ -16,8 +20,32 @@ const (
 	tokenMinus
 	tokenMultiply
 	tokenDivide
+	tokenIntDivide
+	tokenModulo
+	tokenPower
 	tokenLeftParen
 	tokenRightParen
+	tokenString
+	tokenIdent
+	tokenPipe
+	tokenComma
+	tokenEqual
+	tokenNotEqual
+	tokenLess
+	tokenLessEqual
+	tokenGreater
+	tokenGreaterEqual
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenQuestion
+	tokenCoalesce
+	tokenColon
+	tokenBitAnd
+	tokenBitXor
+	tokenBitNot
+	tokenShiftLeft
+	tokenShiftRight
 	tokenEOF
 	tokenError
 )
@@ -62,17 +90,111 @@ func (l *lexer) nextToken() token {
 		l.pos++
 		return token{typ: tokenMinus, value: "-"}
 	case '*':
+		if l.peek(1) == '*' {
+			l.pos += 2
+			return token{typ: tokenPower, value: "**"}
+		}
 		l.pos++
 		return token{typ: tokenMultiply, value: "*"}
 	case '/':
+		if l.peek(1) == '/' {
+			l.pos += 2
+			return token{typ: tokenIntDivide, value: "//"}
+		}
 		l.pos++
 		return token{typ: tokenDivide, value: "/"}
+	case '%':
+		l.pos++
+		return token{typ: tokenModulo, value: "%"}
 	case '(':
 		l.pos++
 		return token{typ: tokenLeftParen, value: "("}
 	case ')':
 		l.pos++
 		return token{typ: tokenRightParen, value: ")"}
+	case '|':
+		if l.peek(1) == '|' {
+			l.pos += 2
+			return token{typ: tokenOr, value: "||"}
+		}
+		l.pos++
+		return token{typ: tokenPipe, value: "|"}
+	case '&':
+		if l.peek(1) == '&' {
+			l.pos += 2
+			return token{typ: tokenAnd, value: "&&"}
+		}
+		l.pos++
+		return token{typ: tokenBitAnd, value: "&"}
+	case '^':
+		l.pos++
+		return token{typ: tokenBitXor, value: "^"}
+	case '~':
+		l.pos++
+		return token{typ: tokenBitNot, value: "~"}
+	case '=':
+		if l.peek(1) == '=' {
+			l.pos += 2
+			return token{typ: tokenEqual, value: "=="}
+		}
+	case '!':
+		if l.peek(1) == '=' {
+			l.pos += 2
+			return token{typ: tokenNotEqual, value: "!="}
+		}
+		l.pos++
+		return token{typ: tokenNot, value: "!"}
+	case '<':
+		if l.peek(1) == '<' {
+			l.pos += 2
+			return token{typ: tokenShiftLeft, value: "<<"}
+		}
+		if l.peek(1) == '=' {
+			l.pos += 2
+			return token{typ: tokenLessEqual, value: "<="}
+		}
+		l.pos++
+		return token{typ: tokenLess, value: "<"}
+	case '>':
+		if l.peek(1) == '>' {
+			l.pos += 2
+			return token{typ: tokenShiftRight, value: ">>"}
+		}
+		if l.peek(1) == '=' {
+			l.pos += 2
+			return token{typ: tokenGreaterEqual, value: ">="}
+		}
+		l.pos++
+		return token{typ: tokenGreater, value: ">"}
+	case '?':
+		if l.peek(1) == '?' {
+			l.pos += 2
+			return token{typ: tokenCoalesce, value: "??"}
+		}
+		l.pos++
+		return token{typ: tokenQuestion, value: "?"}
+	case ':':
+		l.pos++
+		return token{typ: tokenColon, value: ":"}
+	case ',':
+		l.pos++
+		return token{typ: tokenComma, value: ","}
+	case '"':
+		return l.scanString()
+	}
+
+	// Identifier (filter or function name)
+	if unicode.IsLetter(rune(ch)) || ch == '_' {
+		start := l.pos
+		for l.pos < len(l.input) {
+			ch := l.input[l.pos]
+			if unicode.IsLetter(rune(ch)) || unicode.IsDigit(rune(ch)) || ch == '_' {
+				l.pos++
+			} else {
+				break
+			}
+		}
+		return token{typ: tokenIdent, value: l.input[start:l.pos]}
 	}
 
 	// YAML reference (starts with .)
@@ -114,16 +236,17 @@ func (l *lexer) nextToken() token {
 		return token{typ: tokenError, value: "$"}
 	}
 
-	// Number (integer or float)
+	// Number: integer or float with optional exponent, 0x/0o/0b integer,
+	// with optional '_' digit separators; validated by parseNumber
 	if unicode.IsDigit(rune(ch)) {
 		start := l.pos
-		hasDecimal := false
+		hex := ch == '0' && lower(l.peek(1)) == 'x'
+		l.pos++
 		for l.pos < len(l.input) {
 			ch := l.input[l.pos]
-			if unicode.IsDigit(rune(ch)) {
-				l.pos++
-			} else if ch == '.' && !hasDecimal {
-				hasDecimal = true
+			prev := lower(l.input[l.pos-1])
+			if unicode.IsLetter(rune(ch)) || unicode.IsDigit(rune(ch)) || ch == '_' || ch == '.' ||
+				(ch == '+' || ch == '-') && (!hex && prev == 'e' || hex && prev == 'p') {
 				l.pos++
 			} else {
 				break
@@ -138,10 +261,44 @@ func (l *lexer) nextToken() token {
 	return token{typ: tokenError, value: string(ch)}
 }
 
+// peek returns the byte at offset from the current position, or 0 past the end
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.input) {
+		return l.input[l.pos+offset]
+	}
+	return 0
+}
+
+// scanString scans a double-quoted string literal with Go escape sequences
+func (l *lexer) scanString() token {
+	start := l.pos
+	l.pos++ // opening quote
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '\\':
+			l.pos += 2
+		case '"':
+			l.pos++
+			value, err := strconv.Unquote(l.input[start:l.pos])
+			if err != nil {
+				return token{typ: tokenError, value: l.input[start:l.pos]}
+			}
+			return token{typ: tokenString, value: value}
+		default:
+			l.pos++
+		}
+	}
+	l.pos = len(l.input)
+	return token{typ: tokenError, value: l.input[start:]}
+}
+
 // parser implements a recursive descent parser for arithmetic expressions
 type parser struct {
 	lexer   *lexer
 	current token
+	// depth counts enclosing parentheses and call argument lists. At depth 0,
+	// '|' starts a filter; nested, it is bitwise or.
+	depth int
 }
 
 // newParser creates a new parser
@@ -175,17 +332,71 @@ func Parse(input string) (Node, error) {
 	return node, nil
 }
 
-// parseExpression parses addition and subtraction (lowest precedence)
+// parseExpression parses a full expression, starting with the conditional
+// operator (lowest precedence): cond ? then : else, which is right-associative
 func (p *parser) parseExpression() (Node, error) {
-	left, err := p.parseTerm()
+	cond, err := p.parseCoalesce()
 	if err != nil {
 		return nil, err
 	}
+	if p.current.typ != tokenQuestion {
+		return cond, nil
+	}
+	p.advance()
 
-	for p.current.typ == tokenPlus || p.current.typ == tokenMinus {
+	then, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.current.typ != tokenColon {
+		return nil, fmt.Errorf("expected ':' in conditional expression, got %s", p.current.value)
+	}
+	p.advance()
+
+	otherwise, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	return &ConditionalNode{Cond: cond, Then: then, Else: otherwise}, nil
+}
+
+// parseCoalesce parses the null-coalescing operator ??
+func (p *parser) parseCoalesce() (Node, error) {
+	return p.parseBinary(p.parseOr, tokenCoalesce)
+}
+
+// parseOr parses logical or ||
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary(p.parseAnd, tokenOr)
+}
+
+// parseAnd parses logical and &&
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary(p.parseEquality, tokenAnd)
+}
+
+// parseEquality parses == and !=
+func (p *parser) parseEquality() (Node, error) {
+	return p.parseBinary(p.parseComparison, tokenEqual, tokenNotEqual)
+}
+
+// parseComparison parses <, <=, > and >=
+func (p *parser) parseComparison() (Node, error) {
+	return p.parseBinary(p.parseAdditive, tokenLess, tokenLessEqual, tokenGreater, tokenGreaterEqual)
+}
+
+// parseBinary parses a left-associative chain of the given operators whose
+// operands are parsed by next
+func (p *parser) parseBinary(next func() (Node, error), ops ...tokenType) (Node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.currentIs(ops...) {
 		op := p.current.value
 		p.advance()
-		right, err := p.parseTerm()
+		right, err := next()
 		if err != nil {
 			return nil, err
 		}
@@ -195,17 +406,38 @@ func (p *parser) parseExpression() (Node, error) {
 	return left, nil
 }
 
-// parseTerm parses multiplication and division (higher precedence)
+// currentIs reports whether the current token has one of the given types
+func (p *parser) currentIs(types ...tokenType) bool {
+	for _, t := range types {
+		if p.current.typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAdditive parses addition, subtraction, bitwise xor and, inside
+// parentheses, bitwise or (as in Go)
+func (p *parser) parseAdditive() (Node, error) {
+	ops := []tokenType{tokenPlus, tokenMinus, tokenBitXor}
+	if p.depth > 0 {
+		ops = append(ops, tokenPipe)
+	}
+	return p.parseBinary(p.parseTerm, ops...)
+}
+
+// parseTerm parses multiplication, division, integer division, modulo,
+// bitwise and and shifts (higher precedence, as in Go)
 func (p *parser) parseTerm() (Node, error) {
-	left, err := p.parseFactor()
+	left, err := p.parseUnary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.current.typ == tokenMultiply || p.current.typ == tokenDivide {
+	for p.currentIs(tokenMultiply, tokenDivide, tokenIntDivide, tokenModulo, tokenBitAnd, tokenShiftLeft, tokenShiftRight) {
 		op := p.current.value
 		p.advance()
-		right, err := p.parseFactor()
+		right, err := p.parseUnary()
 		if err != nil {
 			return nil, err
 		}
@@ -215,27 +447,75 @@ func (p *parser) parseTerm() (Node, error) {
 	return left, nil
 }
 
-// parseFactor parses numbers, references, and parenthesized expressions
+// parseUnary parses the prefix operators !, -, + and ~ (bitwise not)
+func (p *parser) parseUnary() (Node, error) {
+	if !p.currentIs(tokenNot, tokenMinus, tokenPlus, tokenBitNot) {
+		return p.parsePower()
+	}
+	op := p.current.value
+	p.advance()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &UnaryOpNode{Op: op, Operand: operand}, nil
+}
+
+// parsePower parses exponentiation, which binds tighter than unary minus on
+// its left (-2 ** 2 is -4) and is right-associative (2 ** 3 ** 2 is 2 ** 9)
+func (p *parser) parsePower() (Node, error) {
+	base, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	if p.current.typ != tokenPower {
+		return base, nil
+	}
+	op := p.current.value
+	p.advance()
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryOpNode{Left: base, Op: op, Right: exponent}, nil
+}
+
+// literals are the keywords parsed as constants rather than function names
+var literals = map[string]Value{
+	"true":  BoolValue(true),
+	"false": BoolValue(false),
+	"null":  NullValue(),
+}
+
+// parseFactor parses numbers, references, string and keyword literals,
+// function calls, and parenthesized expressions
 func (p *parser) parseFactor() (Node, error) {
 	switch p.current.typ {
 	case tokenNumber:
 		value := p.current.value
 		p.advance()
-		// Parse as float to support both int and float
-		f, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid number: %s", value)
-		}
-		return &NumberNode{Value: f}, nil
+		return parseNumber(value)
 
 	case tokenReference:
 		path := p.current.value
 		p.advance()
 		return &ReferenceNode{Path: path}, nil
 
+	case tokenString:
+		value := p.current.value
+		p.advance()
+		return &StringNode{Value: value}, nil
+
+	case tokenIdent:
+		if value, ok := literals[p.current.value]; ok {
+			p.advance()
+			return &LiteralNode{Value: value}, nil
+		}
+		return p.parseCall()
+
 	case tokenLeftParen:
 		p.advance()
-		node, err := p.parseExpression()
+		node, err := p.parseNested()
 		if err != nil {
 			return nil, err
 		}
@@ -250,6 +530,129 @@ func (p *parser) parseFactor() (Node, error) {
 	}
 }
 
+// parseNumber converts a number literal. Integer literals are int64, or exact
+// decimals beyond its range, so that large integers keep their precision.
+func parseNumber(literal string) (Node, error) {
+	v, err := parseNumeric(literal)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) && v.Kind() == KindInt {
+			r, ok := new(big.Rat).SetString(strings.ReplaceAll(literal, "_", ""))
+			if !ok {
+				return nil, fmt.Errorf("integer literal out of range: %s", literal)
+			}
+			f, _ := r.Float64()
+			return &NumberNode{Value: f, Exact: DecimalValue(r)}, nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("number literal out of range: %s", literal)
+		}
+		d, derr := time.ParseDuration(literal)
+		q, isQuantity := parseQuantity(literal)
+		var amount float64
+		if isQuantity {
+			amount, _ = q.d.Float64()
+		}
+		switch {
+		case derr == nil && isQuantity:
+			// Like 500m in YAML: minutes or millis, depending on the other operand
+			return &NumberNode{Value: amount, Exact: StringValue(literal)}, nil
+		case derr == nil:
+			return &NumberNode{Value: d.Seconds(), Exact: DurationValue(d)}, nil
+		case isQuantity:
+			return &NumberNode{Value: amount, Exact: q}, nil
+		}
+		return nil, fmt.Errorf("invalid number: %s", literal)
+	}
+	return &NumberNode{Value: v.Float(), Exact: v}, nil
+}
+
+// parseNumeric parses a number in any of the literal forms: decimal integers
+// (42), floats with optional exponent (1.5, 1e6), hexadecimal (0x1F), octal
+// (0o755) and binary (0b1010) integers, each with optional '_' separators
+// between digits (1_000_000) and an optional sign. Unlike Go, a leading zero
+// does not make an integer octal (0755 is 755).
+func parseNumeric(s string) (Value, error) {
+	body := s
+	if len(body) > 0 && (body[0] == '+' || body[0] == '-') {
+		body = body[1:]
+	}
+	if len(body) > 1 && body[0] == '0' && strings.IndexByte("xob", lower(body[1])) >= 0 {
+		if lower(body[1]) == 'x' && strings.ContainsAny(body, ".pP") {
+			f, err := strconv.ParseFloat(s, 64)
+			return FloatValue(f), err
+		}
+		i, err := strconv.ParseInt(s, 0, 64)
+		return IntValue(i), err
+	}
+
+	if body == "" || !isDigit(body[0]) && !(body[0] == '.' && len(body) > 1 && isDigit(body[1])) {
+		return Value{}, strconv.ErrSyntax
+	}
+	for i := 0; i < len(body); i++ {
+		if body[i] == '_' && (i == 0 || i == len(body)-1 || !isDigit(body[i-1]) || !isDigit(body[i+1])) {
+			return Value{}, strconv.ErrSyntax
+		}
+	}
+	digits := strings.ReplaceAll(s, "_", "")
+	if strings.ContainsAny(digits, ".eE") {
+		f, err := strconv.ParseFloat(digits, 64)
+		return FloatValue(f), err
+	}
+	i, err := strconv.ParseInt(digits, 10, 64)
+	return IntValue(i), err
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lower returns the lower-case form of an ASCII letter
+func lower(c byte) byte {
+	return c | 0x20
+}
+
+// parseNested parses an expression inside parentheses or an argument list
+func (p *parser) parseNested() (Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	return p.parseExpression()
+}
+
+// parseCall parses a function call: name(arg, ...)
+func (p *parser) parseCall() (Node, error) {
+	name := p.current.value
+	p.advance()
+	if p.current.typ != tokenLeftParen {
+		return nil, fmt.Errorf("unexpected token: %s", name)
+	}
+	p.advance()
+
+	call := &FuncCallNode{Name: name}
+	if p.current.typ != tokenRightParen {
+		for {
+			arg, err := p.parseNested()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			if p.current.typ != tokenComma {
+				break
+			}
+			p.advance()
+		}
+	}
+	if p.current.typ != tokenRightParen {
+		return nil, fmt.Errorf("expected ')' after arguments of %s, got %s", name, p.current.value)
+	}
+	p.advance()
+
+	if err := checkFunction(name, len(call.Args)); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
 // Node represents a node in the AST
 type Node interface {
 	String() string
@@ -270,13 +673,24 @@ func formatFloat(value float64) string {
 	return s
 }
 
-// NumberNode represents a numeric literal
+// NumberNode represents a numeric literal. Value is the literal as a float;
+// Exact, if not null, is its typed value (an int, a float, a duration or a
+// quantity) and is what the literal evaluates to.
 type NumberNode struct {
 	Value float64
+	Exact Value
+}
+
+// value returns the value the literal evaluates to
+func (n *NumberNode) value() Value {
+	if n.Exact.Kind() == KindNull {
+		return FloatValue(n.Value)
+	}
+	return n.Exact
 }
 
 func (n *NumberNode) String() string {
-	return formatFloat(n.Value)
+	return n.value().String()
 }
 
 // ReferenceNode represents a YAML reference or environment variable reference
@@ -288,6 +702,59 @@ func (n *ReferenceNode) String() string {
 	return n.Path
 }
 
+// StringNode represents a double-quoted string literal
+type StringNode struct {
+	Value string
+}
+
+func (n *StringNode) String() string {
+	return strconv.Quote(n.Value)
+}
+
+// LiteralNode represents a true, false or null literal
+type LiteralNode struct {
+	Value Value
+}
+
+func (n *LiteralNode) String() string {
+	return n.Value.String()
+}
+
+// FuncCallNode represents a call of a built-in function
+type FuncCallNode struct {
+	Name string
+	Args []Node
+}
+
+func (n *FuncCallNode) String() string {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// UnaryOpNode represents a prefix operation
+type UnaryOpNode struct {
+	Op      string
+	Operand Node
+}
+
+func (n *UnaryOpNode) String() string {
+	return fmt.Sprintf("(%s%s)", n.Op, n.Operand.String())
+}
+
+// ConditionalNode represents cond ? then : else
+type ConditionalNode struct {
+	Cond Node
+	Then Node
+	Else Node
+}
+
+func (n *ConditionalNode) String() string {
+	return fmt.Sprintf("(%s ? %s : %s)", n.Cond.String(), n.Then.String(), n.Else.String())
+}
+
 // BinaryOpNode represents a binary operation
 type BinaryOpNode struct {
 	Left  Node
@@ -301,43 +768,23 @@ func (n *BinaryOpNode) String() string {
 
 // Eval evaluates the expression with the given resolver function
 // The resolver function takes a reference path and returns its value
+// Eval is the numeric API: references are numbers and the result must be a
+// number. Use EvalValue for strings, bools, lists and maps.
 func Eval(node Node, resolver func(string) (float64, error)) (float64, error) {
-	switch n := node.(type) {
-	case *NumberNode:
-		return n.Value, nil
-
-	case *ReferenceNode:
-		return resolver(n.Path)
-
-	case *BinaryOpNode:
-		left, err := Eval(n.Left, resolver)
+	result, err := EvalValue(node, func(ref string) (Value, error) {
+		f, err := resolver(ref)
 		if err != nil {
-			return 0, err
+			return Value{}, err
 		}
-		right, err := Eval(n.Right, resolver)
-		if err != nil {
-			return 0, err
-		}
-
-		switch n.Op {
-		case "+":
-			return left + right, nil
-		case "-":
-			return left - right, nil
-		case "*":
-			return left * right, nil
-		case "/":
-			if right == 0 {
-				return 0, fmt.Errorf("division by zero")
-			}
-			return left / right, nil
-		default:
-			return 0, fmt.Errorf("unknown operator: %s", n.Op)
-		}
-
-	default:
-		return 0, fmt.Errorf("unknown node type")
+		return FloatValue(f), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if result.Kind() != KindInt && result.Kind() != KindFloat {
+		return 0, fmt.Errorf("type error: expected a number, got %s", result.describe())
 	}
+	return result.Float(), nil
 }
 
 // ParseAndEval is a convenience function that parses and evaluates an expression
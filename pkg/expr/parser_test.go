@@ -41,6 +41,26 @@ func TestParse_Numbers(t *testing.T) {
 	}
 }
 
+func TestNumberNode_Value(t *testing.T) {
+	node, err := Parse("9007199254740993")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	n := node.(*NumberNode)
+	if n.Value != 9007199254740992 || n.Exact.Int() != 9007199254740993 {
+		t.Errorf("got Value %v, Exact %v", n.Value, n.Exact)
+	}
+
+	// Nodes built without an exact value evaluate to their float
+	got, err := EvalValue(&BinaryOpNode{Left: &NumberNode{Value: 1.5}, Op: "*", Right: &NumberNode{Value: 2}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.String() != "3" || got.Kind() != KindFloat {
+		t.Errorf("got %s %s, want float 3", got.Kind(), got)
+	}
+}
+
 func TestParse_References(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -664,3 +684,92 @@ func TestComplexScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_NumericLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Value
+	}{
+		{"1e6", FloatValue(1e6)},
+		{"2.5E-3", FloatValue(0.0025)},
+		{"1e+3 + 1", FloatValue(1001)},
+		{"0x1F", IntValue(31)},
+		{"0XFF", IntValue(255)},
+		{"0o755", IntValue(493)},
+		{"0b1010", IntValue(10)},
+		{"1_000_000", IntValue(1000000)},
+		{"0x_FF_FF", IntValue(65535)},
+		{"1_000.5", FloatValue(1000.5)},
+		{"0755", IntValue(755)},
+		{"0x10 - 1", IntValue(15)},
+		{"0x1e+5", IntValue(35)},
+		{"0x1p-2", FloatValue(0.25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestParse_NumericLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1__000", "invalid number: 1__000"},
+		{"1000_", "invalid number: 1000_"},
+		{"1_.5", "invalid number: 1_.5"},
+		{"0x", "invalid number: 0x"},
+		{"0b102", "invalid number: 0b102"},
+		{"1e", "invalid number: 1e"},
+		{"1.2.3", "invalid number: 1.2.3"},
+		{"12abc", "invalid number: 12abc"},
+		{"1e400", "number literal out of range: 1e400"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestValue_NumericStrings(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Value
+		ok    bool
+	}{
+		{"42", IntValue(42), true},
+		{" -7 ", IntValue(-7), true},
+		{"+3.5", FloatValue(3.5), true},
+		{".5", FloatValue(0.5), true},
+		{"1e6", FloatValue(1e6), true},
+		{"0x1F", IntValue(31), true},
+		{"-0x10", IntValue(-16), true},
+		{"0o755", IntValue(493), true},
+		{"0b1010", IntValue(10), true},
+		{"1_000_000", IntValue(1000000), true},
+		{"inf", Value{}, false},
+		{"NaN", Value{}, false},
+		{"1_", Value{}, false},
+		{"abc", Value{}, false},
+		{"", Value{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := StringValue(tt.input).numeric()
+		if ok != tt.ok || (ok && (got.Kind() != tt.want.Kind() || got.String() != tt.want.String())) {
+			t.Errorf("numeric(%q) = %s, %v, want %s, %v", tt.input, got.describe(), ok, tt.want.describe(), tt.ok)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placeholder is the parsed content of a ${...} placeholder: an expression
+// optionally followed by a chain of filters, e.g. `.name | upper | default "x"`,
+// and a format directive, e.g. `.price * 1.15 :%.2f`
+type Placeholder struct {
+	Expr    Node
+	Filters []*FilterCall
+	// Format is the format string after ':' (see Format); empty if none
+	Format string
+}
+
+func (p *Placeholder) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Expr.String())
+	for _, f := range p.Filters {
+		sb.WriteString(" | ")
+		sb.WriteString(f.String())
+	}
+	if p.Format != "" {
+		sb.WriteString(" :")
+		sb.WriteString(p.Format)
+	}
+	return sb.String()
+}
+
+// FilterCall is a filter applied in a placeholder's filter chain.
+// Args are factors: literals, references or parenthesized expressions.
+type FilterCall struct {
+	Name string
+	Args []Node
+}
+
+func (f *FilterCall) String() string {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	for _, arg := range f.Args {
+		sb.WriteByte(' ')
+		sb.WriteString(arg.String())
+	}
+	return sb.String()
+}
+
+// ParsePlaceholder parses placeholder content (without ${ and }) into an
+// expression and its filter chain. Filter names are checked against the
+// registry and their argument counts are validated.
+func ParsePlaceholder(input string) (*Placeholder, error) {
+	p := newParser(input)
+	if p.current.typ == tokenError {
+		return nil, fmt.Errorf("unexpected character: %s", p.current.value)
+	}
+	node, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	ph := &Placeholder{Expr: node}
+	for p.current.typ == tokenPipe {
+		p.advance()
+		call, err := p.parseFilterCall()
+		if err != nil {
+			return nil, err
+		}
+		ph.Filters = append(ph.Filters, call)
+	}
+
+	if p.current.typ == tokenColon {
+		// The rest of the input is the format string, taken verbatim
+		ph.Format = strings.TrimLeft(p.lexer.input[p.lexer.pos:], " \t")
+		if err := CheckFormat(ph.Format); err != nil {
+			return nil, err
+		}
+		return ph, nil
+	}
+
+	if p.current.typ == tokenError {
+		return nil, fmt.Errorf("unexpected character: %s", p.current.value)
+	}
+	if p.current.typ != tokenEOF {
+		return nil, fmt.Errorf("unexpected token: %s", p.current.value)
+	}
+	return ph, nil
+}
+
+// parseFilterCall parses a filter name followed by its arguments
+func (p *parser) parseFilterCall() (*FilterCall, error) {
+	if p.current.typ != tokenIdent {
+		return nil, fmt.Errorf("expected filter name after '|', got %s", p.current.value)
+	}
+	call := &FilterCall{Name: p.current.value}
+	p.advance()
+
+	for !p.currentIs(tokenPipe, tokenColon, tokenEOF, tokenError) {
+		arg, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+	if p.current.typ == tokenError {
+		return nil, fmt.Errorf("unexpected character: %s", p.current.value)
+	}
+
+	if err := checkFilter(call.Name, len(call.Args)); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
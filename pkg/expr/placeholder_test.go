@@ -0,0 +1,70 @@
+package expr
+
+import "testing"
+
+func TestParsePlaceholder(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"expression only", ".a + 1", "(.a + 1)"},
+		{"single filter", ".name | upper", ".name | upper"},
+		{"filter chain", ".name | upper | trim", ".name | upper | trim"},
+		{"filter with string arg", `.host | default "localhost"`, `.host | default "localhost"`},
+		{"filter with two args", `.s | replace "a" "b"`, `.s | replace "a" "b"`},
+		{"filter with reference arg", ".host | default .fallback", ".host | default .fallback"},
+		{"filter with number arg", ".port | default 8080", ".port | default 8080"},
+		{"filter with expression arg", ".port | default (.base + 1)", ".port | default (.base + 1)"},
+		{"filter with negative number arg", ".port | default -1", ".port | default (-1)"},
+		{"expression before filter", ".a * 2 | default 0", "(.a * 2) | default 0"},
+		{"escaped string", `.x | default "say \"hi\""`, `.x | default "say \"hi\""`},
+		{"brace in string", `.x | join "}"`, `.x | join "}"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ph, err := ParsePlaceholder(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := ph.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaceholder_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unknown filter", ".name | shout", "unknown filter: shout"},
+		{"missing filter name", ".name |", "expected filter name after '|', got "},
+		{"too many args", `.name | upper "x"`, "filter upper expects 0 arguments, got 1"},
+		{"too few args", ".name | default", "filter default expects 1 argument, got 0"},
+		{"replace arg count", `.name | replace "a"`, "filter replace expects 2 arguments, got 1"},
+		{"unterminated string", `.name | default "x`, `unexpected character: "x`},
+		{"filter name is number", ".name | 5", "expected filter name after '|', got 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePlaceholder(tt.input)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_RejectsFilters(t *testing.T) {
+	if _, err := Parse(".name | upper"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
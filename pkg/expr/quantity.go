@@ -0,0 +1,269 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// quantityUnit is a Kubernetes quantity suffix and its multiplier
+type quantityUnit struct {
+	suffix     string
+	multiplier *big.Rat
+}
+
+// Quantity suffixes from largest to smallest. Binary quantities go down to
+// bytes, decimal quantities down to millis.
+var (
+	binaryUnits = []quantityUnit{
+		{"Ei", new(big.Rat).SetInt64(1 << 60)},
+		{"Pi", new(big.Rat).SetInt64(1 << 50)},
+		{"Ti", new(big.Rat).SetInt64(1 << 40)},
+		{"Gi", new(big.Rat).SetInt64(1 << 30)},
+		{"Mi", new(big.Rat).SetInt64(1 << 20)},
+		{"Ki", new(big.Rat).SetInt64(1 << 10)},
+		{"", big.NewRat(1, 1)},
+	}
+	decimalUnits = []quantityUnit{
+		{"E", new(big.Rat).SetInt64(1e18)},
+		{"P", new(big.Rat).SetInt64(1e15)},
+		{"T", new(big.Rat).SetInt64(1e12)},
+		{"G", new(big.Rat).SetInt64(1e9)},
+		{"M", new(big.Rat).SetInt64(1e6)},
+		{"k", new(big.Rat).SetInt64(1e3)},
+		{"", big.NewRat(1, 1)},
+		{"m", big.NewRat(1, 1000)},
+	}
+)
+
+// QuantityValue returns a quantity of amount base units (bytes or cores),
+// rendered with the given suffix where possible
+func QuantityValue(amount *big.Rat, suffix string) Value {
+	return Value{kind: KindQuantity, d: amount, s: suffix}
+}
+
+// quantityUnits returns the unit family of a suffix and the suffix's index
+// in it. No suffix counts as bytes.
+func quantityUnits(suffix string) ([]quantityUnit, int, bool) {
+	for _, units := range [][]quantityUnit{binaryUnits, decimalUnits} {
+		for i, u := range units {
+			if u.suffix == suffix {
+				return units, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// parseQuantity parses a Kubernetes quantity such as 512Mi, 2G or 500m.
+// Plain numbers are not quantities.
+func parseQuantity(s string) (Value, bool) {
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i <= 0 {
+		return Value{}, false
+	}
+	number, suffix := s[:i], s[i:]
+	units, idx, ok := quantityUnits(suffix)
+	if !ok || !isDecimalNumber(strings.TrimLeft(number, "+-")) || len(number)-len(strings.TrimLeft(number, "+-")) > 1 {
+		return Value{}, false
+	}
+	amount, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return Value{}, false
+	}
+	return QuantityValue(amount.Mul(amount, units[idx].multiplier), suffix), true
+}
+
+// isDecimalNumber reports whether s is an unsigned number like 12, 1.5 or .5
+func isDecimalNumber(s string) bool {
+	digits, dots := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case isDigit(s[i]):
+			digits++
+		case s[i] == '.':
+			dots++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && dots <= 1
+}
+
+// quantity returns the value as a quantity. Strings in quantity format are
+// converted unless they are plain numbers.
+func (v Value) quantity() (Value, bool) {
+	switch v.kind {
+	case KindQuantity:
+		return v, true
+	case KindString:
+		if _, ok := v.numeric(); ok {
+			return Value{}, false
+		}
+		return parseQuantity(strings.TrimSpace(v.s))
+	}
+	return Value{}, false
+}
+
+// durationOnly reports whether v is a duration that is not also a quantity
+func (v Value) durationOnly() bool {
+	_, isDuration := v.duration()
+	_, isQuantity := v.quantity()
+	return isDuration && !isQuantity
+}
+
+// quantityOnly reports whether v is a quantity that is not also a duration
+func (v Value) quantityOnly() bool {
+	_, isDuration := v.duration()
+	_, isQuantity := v.quantity()
+	return isQuantity && !isDuration
+}
+
+// ambiguous reports whether v is both a duration and a quantity: a number
+// with the suffix m, which is minutes or millis
+func (v Value) ambiguous() bool {
+	_, isDuration := v.duration()
+	_, isQuantity := v.quantity()
+	return isDuration && isQuantity
+}
+
+// checkAmbiguous rejects arithmetic on a number with the suffix m unless the
+// other operand is a duration or a quantity that tells minutes from millis
+func checkAmbiguous(left, right Value) error {
+	switch {
+	case left.ambiguous() && !right.durationOnly() && !right.quantityOnly():
+		return ambiguousError(left)
+	case right.ambiguous() && !left.durationOnly() && !left.quantityOnly():
+		return ambiguousError(right)
+	}
+	return nil
+}
+
+// ambiguousError asks for an explicit conversion of an ambiguous value
+func ambiguousError(v Value) error {
+	return fmt.Errorf("ambiguous value %s: use duration(%[1]s) for minutes or quantity(%[1]s) for millis", v)
+}
+
+// quantityOp applies an arithmetic operator to a quantity and another
+// quantity or a number. ok is false if neither operand is a quantity.
+func quantityOp(op string, left, right Value) (result Value, ok bool, err error) {
+	if (!left.quantityOnly() && !right.quantityOnly()) || left.durationOnly() || right.durationOnly() {
+		return Value{}, false, nil
+	}
+	l, lok := left.quantity()
+	r, rok := right.quantity()
+
+	var amount *big.Rat
+	suffix := l.s
+	switch {
+	case lok && rok && op == "+":
+		amount = new(big.Rat).Add(l.d, r.d)
+	case lok && rok && op == "-":
+		amount = new(big.Rat).Sub(l.d, r.d)
+	case lok && rok && (op == "/" || op == "//"):
+		// The ratio of two quantities is a number
+		if r.d.Sign() == 0 {
+			return Value{}, true, fmt.Errorf("division by zero")
+		}
+		ratio := new(big.Rat).Quo(l.d, r.d)
+		if op == "//" {
+			ratio = ratFloor(ratio)
+		}
+		return ratNumber(ratio), true, nil
+	case lok && rok:
+		return Value{}, true, typeError(op, left, right)
+	default:
+		// Scale a quantity by a number: q * n, n * q or q / n
+		q, n := l, right
+		if rok {
+			q, n = r, left
+		}
+		factor, isNumber := n.numeric()
+		if !isNumber || (op != "*" && (op != "/" || rok)) {
+			return Value{}, true, typeError(op, left, right)
+		}
+		suffix = q.s
+		if op == "*" {
+			amount = new(big.Rat).Mul(q.d, factor.rat())
+		} else if factor.rat().Sign() == 0 {
+			return Value{}, true, fmt.Errorf("division by zero")
+		} else {
+			amount = new(big.Rat).Quo(q.d, factor.rat())
+		}
+	}
+
+	// Rather than rounding up like Kubernetes, reject amounts finer than the
+	// smallest unit (a byte or a milli)
+	units, _, _ := quantityUnits(suffix)
+	if last := units[len(units)-1]; !new(big.Rat).Quo(amount, last.multiplier).IsInt() {
+		unit := "bytes"
+		if last.suffix != "" {
+			unit = "millis"
+		}
+		return Value{}, true, fmt.Errorf("quantity %s %s %s is not a whole number of %s", left, op, right, unit)
+	}
+	return QuantityValue(amount, suffix), true, nil
+}
+
+// formatQuantity renders an amount with the given suffix, or the next smaller
+// unit of the same family that represents it exactly. Amounts finer than the
+// smallest unit (a byte or a milli) keep their fractional digits.
+func formatQuantity(amount *big.Rat, suffix string) string {
+	units, i, _ := quantityUnits(suffix)
+	for ; i < len(units)-1; i++ {
+		if n := new(big.Rat).Quo(amount, units[i].multiplier); n.IsInt() {
+			return n.Num().String() + units[i].suffix
+		}
+	}
+	last := units[len(units)-1]
+	return formatDecimal(new(big.Rat).Quo(amount, last.multiplier)) + last.suffix
+}
+
+// ratNumber returns r as an int if it is an integer that fits, otherwise as
+// a float
+func ratNumber(r *big.Rat) Value {
+	if r.IsInt() && r.Num().IsInt64() {
+		return IntValue(r.Num().Int64())
+	}
+	f, _ := r.Float64()
+	return FloatValue(f)
+}
+
+// toQuantity converts a quantity or a string in quantity format, such as
+// 500m, to a quantity
+func toQuantity(args []Value) (Value, error) {
+	return quantityArg(args[0])
+}
+
+// quantityArg converts a function argument to a quantity
+func quantityArg(arg Value) (Value, error) {
+	q, ok := arg.quantity()
+	if !ok {
+		return Value{}, fmt.Errorf("type error: expected a quantity, got %s", arg.describe())
+	}
+	return q, nil
+}
+
+// quantityBytes returns the amount of a quantity in base units: bytes, or cores for
+// CPU quantities such as 500m
+func quantityBytes(args []Value) (Value, error) {
+	q, err := quantityArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	return ratNumber(q.d), nil
+}
+
+// toUnit returns the amount of a quantity in the given unit, e.g. Mi
+func toUnit(args []Value) (Value, error) {
+	q, err := quantityArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	units, i, ok := quantityUnits(args[1].String())
+	if !ok {
+		return Value{}, fmt.Errorf("unknown unit %q", args[1].String())
+	}
+	return ratNumber(new(big.Rat).Quo(q.d, units[i].multiplier)), nil
+}
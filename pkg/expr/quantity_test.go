@@ -0,0 +1,146 @@
+package expr
+
+import "testing"
+
+func TestEvalValue_Quantities(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".memory":  "512Mi",
+		".disk":    "2G",
+		".cpu":     "500m",
+		".timeout": "30m",
+		".port":    "8080",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"scale keeps unit", ".memory * 0.75", "384Mi"},
+		{"scale left", "2 * .memory", "1024Mi"},
+		{"smaller unit when needed", ".memory / 1024", "512Ki"},
+		{"bytes when needed", "1Ki * 1.5", "1536"},
+		{"divide by number", ".disk / 4", "500M"},
+		{"millis when needed", "1.5k * 0.0005", "750m"},
+		{"add", ".memory + 512Mi", "1024Mi"},
+		{"add mixed families", ".memory + 1Ki", "524289Ki"},
+		{"ambiguous string next to a quantity", "1k - .cpu", "999500m"},
+		{"millicores scaled", "quantity(.cpu) * 3", "1500m"},
+		{"millicores subtracted", "quantity(.cpu) - 250m", "250m"},
+		{"minutes scaled", "duration(5m) * 1.5", "7m30s"},
+		{"minutes halved", "duration(1m) * 0.5", "30s"},
+		{"minutes doubled", "duration(.timeout) * 2", "1h0m0s"},
+		{"negate minutes", "-duration(.timeout)", "-30m0s"},
+		{"ambiguous string next to duration", ".timeout + 1h", "1h30m0s"},
+		{"quantity of a quantity", "quantity(.memory)", "512Mi"},
+		{"duration of a duration", "duration(90s)", "1m30s"},
+		{"negate", "-.memory", "-512Mi"},
+		{"ratio", ".memory / 1Mi", "512"},
+		{"fractional ratio", ".memory / 1Gi", "0.5"},
+		{"integer ratio", ".disk // 3M", "666"},
+		{"literal", "1.5Gi", "1536Mi"},
+		{"compare", ".memory < 1G", "true"},
+		{"equal", `.memory == "0.5Gi"`, "true"},
+		{"numeric string stays a number", ".port * 2", "16160"},
+		{"bytes", "bytes(.memory)", "536870912"},
+		{"cores", "bytes(.cpu)", "0.5"},
+		{"to_unit", `to_unit(.memory * 0.75, "Mi")`, "384"},
+		{"to_unit decimal", `to_unit(.disk, "M")`, "2000"},
+		{"to_unit fractional", `to_unit(.memory, "Gi")`, "0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got.describe(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_QuantityKinds(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{".memory": "512Mi"})
+
+	tests := []struct {
+		input string
+		want  Kind
+	}{
+		{".memory * 2", KindQuantity},
+		{".memory / 1Mi", KindInt},
+		{"bytes(.memory)", KindInt},
+		{`to_unit(.memory, "Gi")`, KindFloat},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAndEvalValue(tt.input, resolver)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if got.Kind() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.input, got.Kind(), tt.want)
+		}
+	}
+}
+
+func TestEvalValue_QuantityErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".memory": "512Mi",
+		".cpu":    "500m",
+		".name":   "api",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"add number", ".memory + 1", `type error: cannot apply + to string "512Mi" and int 1`},
+		{"multiply quantities", ".memory * 2Mi", `type error: cannot apply * to string "512Mi" and quantity 2Mi`},
+		{"divide by zero", ".memory / 0", "division by zero"},
+		{"ratio by zero", ".memory / 0Mi", "division by zero"},
+		{"bytes of string", "bytes(.name)", `bytes: type error: expected a quantity, got string "api"`},
+		{"unknown unit", `to_unit(.memory, "MB")`, `to_unit: unknown unit "MB"`},
+		{"invalid literal", "512Mx", "invalid number: 512Mx"},
+		{"finer than bytes", ".memory * 0.001", "quantity 512Mi * 0.001 is not a whole number of bytes"},
+		{"finer than millis", "1k * 0.0000001", "quantity 1k * 0.0000001 is not a whole number of millis"},
+		{"finer than bytes across families", ".memory + 1m", `quantity 512Mi + 1m is not a whole number of bytes`},
+		{"ambiguous scaled", ".cpu * 3", "ambiguous value 500m: use duration(500m) for minutes or quantity(500m) for millis"},
+		{"ambiguous literal scaled", "5m * 1.5", "ambiguous value 5m: use duration(5m) for minutes or quantity(5m) for millis"},
+		{"ambiguous operands", ".cpu - 250m", "ambiguous value 500m: use duration(500m) for minutes or quantity(500m) for millis"},
+		{"negate ambiguous", "-.cpu", "ambiguous value 500m: use duration(500m) for minutes or quantity(500m) for millis"},
+		{"quantity of string", "quantity(.name)", `quantity: type error: expected a quantity, got string "api"`},
+		{"duration of quantity", "duration(.memory)", `duration: type error: expected a duration, got string "512Mi"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	valid := []string{"512Mi", "2G", "500m", "1.5Gi", ".5Ki", "-1k", "+2Ti", "3E"}
+	for _, s := range valid {
+		if _, ok := parseQuantity(s); !ok {
+			t.Errorf("%q: expected a quantity", s)
+		}
+	}
+
+	invalid := []string{"512", "Mi", "1..5Mi", "1-2Mi", "--1Mi", "1e3Mi", "1/2Mi", "512MB", "30s"}
+	for _, s := range invalid {
+		if _, ok := parseQuantity(s); ok {
+			t.Errorf("%q: expected no quantity", s)
+		}
+	}
+}
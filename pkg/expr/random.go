@@ -0,0 +1,185 @@
+package expr
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// maxRandomLength limits the length of random_string results
+const maxRandomLength = 65536
+
+// randomCharsets are the named character sets of random_string. Any other
+// charset argument is used as the set of characters itself.
+var randomCharsets = map[string]string{
+	"alnum":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits": "0123456789",
+	"hex":    "0123456789abcdef",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789" +
+		"!#%+-.:=?@^_~",
+}
+
+// Generator produces the values of random_string, random_int and uuid and
+// records them by call, so that restoring Values from an earlier run
+// reproduces its values. Every call generates a new value, except calls with
+// a name argument: calls with the same arguments and name share one value.
+type Generator struct {
+	rand *rand.Rand
+	// Values holds the generated values keyed by call: named calls by their
+	// text, e.g. `random_string(16, "alnum", "db")`, and other calls by their
+	// text and occurrence, e.g. `random_string(16)#2`. Nil Values disables
+	// recording.
+	Values map[string]Value
+	// calls counts the occurrences of unnamed calls since the last Rewind
+	calls map[string]int
+}
+
+// NewGenerator returns a recording Generator drawing from a cryptographically
+// secure source, suitable for passwords
+func NewGenerator() *Generator {
+	return &Generator{rand: rand.New(cryptoSource{}), Values: map[string]Value{}, calls: map[string]int{}}
+}
+
+// NewSeededGenerator returns a recording Generator whose values are
+// determined by seed, e.g. for tests
+func NewSeededGenerator(seed uint64) *Generator {
+	return &Generator{rand: rand.New(rand.NewPCG(seed, 0)), Values: map[string]Value{}, calls: map[string]int{}}
+}
+
+// Rewind starts a new rendering of the same templates: unnamed calls are
+// counted from the first occurrence again, so that each one gets the value
+// recorded for it in an earlier rendering
+func (g *Generator) Rewind() {
+	clear(g.calls)
+}
+
+// secureRandom serves evaluators without a Generator; it does not record
+var secureRandom = &Generator{rand: rand.New(cryptoSource{})}
+
+// cryptoSource is a rand.Source reading crypto/rand
+type cryptoSource struct{}
+
+// Uint64 returns a random uint64 from crypto/rand
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	_, _ = crand.Read(b[:]) // never returns an error
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// generator returns the evaluator's Generator, or secureRandom
+func (e *Evaluator) generator() *Generator {
+	if e.Generator == nil {
+		return secureRandom
+	}
+	return e.Generator
+}
+
+// generate returns the recorded value of a call, or records the value
+// produced by fn. Named calls share their value; unnamed calls are recorded
+// by occurrence.
+func (g *Generator) generate(name string, args []Value, named bool, fn func(r *rand.Rand) (Value, error)) (Value, error) {
+	if g.Values == nil {
+		return fn(g.rand)
+	}
+	key := callKey(name, args)
+	if !named {
+		g.calls[key]++
+		key += "#" + strconv.Itoa(g.calls[key])
+	}
+	if v, ok := g.Values[key]; ok {
+		return v, nil
+	}
+	v, err := fn(g.rand)
+	if err == nil {
+		g.Values[key] = v
+	}
+	return v, err
+}
+
+// callKey formats a call with evaluated arguments, quoting strings
+func callKey(name string, args []Value) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.String()
+		if arg.Kind() == KindString {
+			parts[i] = strconv.Quote(parts[i])
+		}
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// randomString returns n random characters from a charset (default alnum).
+// The optional third argument names the value: calls with the same arguments
+// and name share it.
+func (e *Evaluator) randomString(args []Value) (Value, error) {
+	n, err := args[0].toInt()
+	if err != nil {
+		return Value{}, err
+	}
+	if n < 0 || n > maxRandomLength {
+		return Value{}, fmt.Errorf("length %d out of range 0 to %d", n, maxRandomLength)
+	}
+	charset := randomCharsets["alnum"]
+	if len(args) > 1 {
+		charset = args[1].String()
+		if named, ok := randomCharsets[charset]; ok {
+			charset = named
+		}
+	}
+	chars := []rune(charset)
+	if len(chars) == 0 {
+		return Value{}, fmt.Errorf("empty charset")
+	}
+
+	return e.generator().generate("random_string", args, len(args) == 3, func(r *rand.Rand) (Value, error) {
+		result := make([]rune, n)
+		for i := range result {
+			result[i] = chars[r.IntN(len(chars))]
+		}
+		return StringValue(string(result)), nil
+	})
+}
+
+// randomInt returns a random integer between lo and hi inclusive. The
+// optional third argument names the value (see randomString).
+func (e *Evaluator) randomInt(args []Value) (Value, error) {
+	lo, err := args[0].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+	hi, err := args[1].toInt64()
+	if err != nil {
+		return Value{}, err
+	}
+	if lo > hi {
+		return Value{}, fmt.Errorf("lower bound %d is greater than upper bound %d", lo, hi)
+	}
+
+	return e.generator().generate("random_int", args, len(args) == 3, func(r *rand.Rand) (Value, error) {
+		span := uint64(hi-lo) + 1
+		if span == 0 {
+			// The full int64 range
+			return IntValue(int64(r.Uint64())), nil
+		}
+		return IntValue(lo + int64(r.Uint64N(span))), nil
+	})
+}
+
+// uuid returns a random (version 4) UUID. The optional argument names
+// the value (see randomString).
+func (e *Evaluator) uuid(args []Value) (Value, error) {
+	return e.generator().generate("uuid", args, len(args) == 1, func(r *rand.Rand) (Value, error) {
+		var u [16]byte
+		binary.BigEndian.PutUint64(u[0:8], r.Uint64())
+		binary.BigEndian.PutUint64(u[8:16], r.Uint64())
+		u[6] = u[6]&0x0f | 0x40 // version 4
+		u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+		return StringValue(formatUUID(u)), nil
+	})
+}
@@ -0,0 +1,143 @@
+package expr
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func evalRandom(t *testing.T, e *Evaluator, input string) Value {
+	t.Helper()
+	got, err := e.Eval(mustParse(t, input), valueResolver(nil))
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", input, err)
+	}
+	return got
+}
+
+func TestRandomFunctions(t *testing.T) {
+	e := &Evaluator{Generator: NewSeededGenerator(1)}
+	tests := []struct {
+		input   string
+		pattern string
+	}{
+		{"random_string(16)", `^[A-Za-z0-9]{16}$`},
+		{`random_string(8, "hex")`, `^[0-9a-f]{8}$`},
+		{`random_string(6, "digits")`, `^[0-9]{6}$`},
+		{`random_string(5, "lower")`, `^[a-z]{5}$`},
+		{`random_string(5, "upper")`, `^[A-Z]{5}$`},
+		{`random_string(5, "alpha")`, `^[A-Za-z]{5}$`},
+		{`random_string(20, "symbols")`, `^[A-Za-z0-9!#%+\-.:=?@^_~]{20}$`},
+		{`random_string(10, "ab")`, `^[ab]{10}$`},
+		{`random_string(0)`, `^$`},
+		{"random_int(1, 6)", `^[1-6]$`},
+		{"random_int(-3, -3)", `^-3$`},
+		{"uuid()", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := evalRandom(t, e, tt.input).String()
+			if !regexp.MustCompile(tt.pattern).MatchString(got) {
+				t.Errorf("got %q, want match of %s", got, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestRandomFunctions_Seeded(t *testing.T) {
+	generate := func(seed uint64) string {
+		e := &Evaluator{Generator: NewSeededGenerator(seed)}
+		var parts []string
+		for _, input := range []string{"random_string(12)", "random_int(0, 1000000)", "uuid()"} {
+			parts = append(parts, evalRandom(t, e, input).String())
+		}
+		return strings.Join(parts, "/")
+	}
+
+	first, second := generate(42), generate(42)
+	if first != second {
+		t.Errorf("same seed generated %q and %q", first, second)
+	}
+	if other := generate(43); other == first {
+		t.Errorf("different seeds generated %q", other)
+	}
+}
+
+func TestRandomFunctions_Recorded(t *testing.T) {
+	g := NewSeededGenerator(7)
+	e := &Evaluator{Generator: g}
+
+	first := evalRandom(t, e, "random_string(16)").String()
+	second := evalRandom(t, e, "random_string(16)").String()
+	if first == second {
+		t.Errorf("repeated unnamed call reused %q", first)
+	}
+	named := evalRandom(t, e, `random_string(16, "alnum", "db")`).String()
+	if again := evalRandom(t, e, `random_string(16, "alnum", "db")`).String(); again != named {
+		t.Errorf("repeated named call generated %q, want %q", again, named)
+	}
+	if other := evalRandom(t, e, `random_string(16, "alnum", "cache")`).String(); other == named {
+		t.Errorf("differently named call generated the same value %q", other)
+	}
+	if evalRandom(t, e, "uuid()").String() == evalRandom(t, e, "uuid()").String() {
+		t.Error("repeated uuid() generated the same value")
+	}
+
+	want := map[string]string{
+		"random_string(16)#1":              first,
+		"random_string(16)#2":              second,
+		`random_string(16, "alnum", "db")`: named,
+	}
+	for call, value := range want {
+		if got := g.Values[call]; got.String() != value {
+			t.Errorf("recorded %s = %q, want %q", call, got.String(), value)
+		}
+	}
+
+	// After Rewind, unnamed calls get the values of their occurrence again
+	g.Rewind()
+	if got := evalRandom(t, e, "random_string(16)").String(); got != first {
+		t.Errorf("after Rewind got %q, want %q", got, first)
+	}
+	if got := evalRandom(t, e, "random_string(16)").String(); got != second {
+		t.Errorf("after Rewind got %q, want %q", got, second)
+	}
+
+	restored := NewSeededGenerator(8)
+	restored.Values["random_string(16)#1"] = StringValue("restored")
+	if got := evalRandom(t, &Evaluator{Generator: restored}, "random_string(16)").String(); got != "restored" {
+		t.Errorf("got %q, want restored value", got)
+	}
+}
+
+func TestRandomFunctions_WithoutGenerator(t *testing.T) {
+	e := &Evaluator{}
+	first := evalRandom(t, e, "random_string(32)").String()
+	second := evalRandom(t, e, "random_string(32)").String()
+	if first == second {
+		t.Errorf("unrecorded calls generated the same value %q", first)
+	}
+	if secureRandom.Values != nil {
+		t.Errorf("values recorded without a Generator: %v", secureRandom.Values)
+	}
+}
+
+func TestRandomFunctions_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"random_string(-1)", "random_string: length -1 out of range 0 to 65536"},
+		{"random_string(100000)", "random_string: length 100000 out of range 0 to 65536"},
+		{`random_string(4, "")`, "random_string: empty charset"},
+		{"random_int(5, 1)", "random_int: lower bound 5 is greater than upper bound 1"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseAndEvalValue(tt.input, valueResolver(nil))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package expr
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version (https://semver.org), optionally
+// prefixed with v
+type semver struct {
+	prefix              string
+	major, minor, patch int64
+	prerelease, build   string
+}
+
+// parseSemver parses a semantic version such as 1.4.2, v2.0.0-rc.1 or
+// 1.0.0+build.5
+func parseSemver(s string) (semver, error) {
+	var v semver
+	rest := s
+	if strings.HasPrefix(rest, "v") {
+		v.prefix, rest = "v", rest[1:]
+	}
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		rest, v.build = rest[:i], rest[i+1:]
+		if !validIdentifiers(v.build, false) {
+			return semver{}, fmt.Errorf("invalid semantic version %q: invalid build metadata", s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		rest, v.prerelease = rest[:i], rest[i+1:]
+		if !validIdentifiers(v.prerelease, true) {
+			return semver{}, fmt.Errorf("invalid semantic version %q: invalid prerelease", s)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("invalid semantic version %q: expected major.minor.patch", s)
+	}
+	numbers := []*int64{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 || part[0] == '+' || (len(part) > 1 && part[0] == '0') {
+			return semver{}, fmt.Errorf("invalid semantic version %q: invalid number %q", s, part)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// validIdentifiers reports whether s is a dot-separated list of non-empty
+// alphanumeric identifiers. Numeric prerelease identifiers must not have
+// leading zeros.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			if !isDigit(c) && c != '-' && (lower(c) < 'a' || lower(c) > 'z') {
+				return false
+			}
+			numeric = numeric && isDigit(c)
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the version
+func (v semver) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.prefix, v.major, v.minor, v.patch)
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	if v.build != "" {
+		s += "+" + v.build
+	}
+	return s
+}
+
+// compareSemver orders two versions by semver precedence: build metadata is
+// ignored and a prerelease sorts before its release
+func compareSemver(a, b semver) int {
+	if c := cmp.Compare(a.major, b.major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.minor, b.minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.patch, b.patch); c != 0 {
+		return c
+	}
+	switch {
+	case a.prerelease == b.prerelease:
+		return 0
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a.prerelease, "."), strings.Split(b.prerelease, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.ParseUint(as[i], 10, 64)
+		bn, berr := strconv.ParseUint(bs[i], 10, 64)
+		var c int
+		switch {
+		case aerr == nil && berr == nil:
+			c = cmp.Compare(an, bn)
+		case aerr == nil:
+			// Numeric identifiers sort before alphanumeric ones
+			c = -1
+		case berr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// semverArg parses a function argument as a semantic version
+func semverArg(arg Value) (semver, error) {
+	return parseSemver(strings.TrimSpace(arg.String()))
+}
+
+// semverField returns a part of a version
+func semverField(field func(v semver) Value) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		v, err := semverArg(args[0])
+		if err != nil {
+			return Value{}, err
+		}
+		return field(v), nil
+	}
+}
+
+// semverBump increments a version. Like npm, a prerelease of the bumped
+// version is released instead (1.3.0-rc.1 bumps to 1.3.0 as a minor).
+// Prerelease and build metadata are dropped.
+func semverBump(bump func(v *semver)) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		v, err := semverArg(args[0])
+		if err != nil {
+			return Value{}, err
+		}
+		bump(&v)
+		v.prerelease, v.build = "", ""
+		return StringValue(v.String()), nil
+	}
+}
+
+// semverPart returns the numeric part of a version selected by part
+func semverPart(part func(v semver) int64) func(args []Value) (Value, error) {
+	return semverField(func(v semver) Value { return IntValue(part(v)) })
+}
+
+// semverCompare compares two versions: -1 if a < b, 0 if equal, 1 if a > b
+func semverCompare(args []Value) (Value, error) {
+	a, err := semverArg(args[0])
+	if err != nil {
+		return Value{}, err
+	}
+	b, err := semverArg(args[1])
+	if err != nil {
+		return Value{}, err
+	}
+	return IntValue(int64(compareSemver(a, b))), nil
+}
+
+// semverValid reports whether the argument is a valid semantic version
+func semverValid(args []Value) (Value, error) {
+	_, err := semverArg(args[0])
+	return BoolValue(err == nil), nil
+}
+
+// bumpMajor, bumpMinor and bumpPatch increment a version (see semverBump)
+func bumpMajor(v *semver) {
+	if v.prerelease == "" || v.minor != 0 || v.patch != 0 {
+		v.major++
+	}
+	v.minor, v.patch = 0, 0
+}
+
+func bumpMinor(v *semver) {
+	if v.prerelease == "" || v.patch != 0 {
+		v.minor++
+	}
+	v.patch = 0
+}
+
+func bumpPatch(v *semver) {
+	if v.prerelease == "" {
+		v.patch++
+	}
+}
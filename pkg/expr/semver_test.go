@@ -0,0 +1,80 @@
+package expr
+
+import "testing"
+
+func TestSemverFunctions(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".version": "1.4.2",
+		".rc":      "v2.0.0-rc.1+build.7",
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"major", "semver_major(.version)", IntValue(1)},
+		{"minor", "semver_minor(.version)", IntValue(4)},
+		{"patch", "semver_patch(.version)", IntValue(2)},
+		{"v prefix", "semver_major(.rc)", IntValue(2)},
+		{"prerelease", "semver_prerelease(.rc)", StringValue("rc.1")},
+		{"build", "semver_build(.rc)", StringValue("build.7")},
+		{"no prerelease", "semver_prerelease(.version)", StringValue("")},
+		{"bump major", "semver_bump_major(.version)", StringValue("2.0.0")},
+		{"bump minor", "semver_bump_minor(.version)", StringValue("1.5.0")},
+		{"bump patch", "semver_bump_patch(.version)", StringValue("1.4.3")},
+		{"bump releases prerelease", "semver_bump_major(.rc)", StringValue("v2.0.0")},
+		{"bump minor past prerelease", `semver_bump_minor("1.2.3-beta")`, StringValue("1.3.0")},
+		{"bump patch releases prerelease", `semver_bump_patch("1.2.3-beta")`, StringValue("1.2.3")},
+		{"compare less", `semver_compare(.version, "1.10.0")`, IntValue(-1)},
+		{"compare greater", `semver_compare(.version, "1.4.1")`, IntValue(1)},
+		{"compare ignores build", `semver_compare("1.0.0+a", "1.0.0+b")`, IntValue(0)},
+		{"prerelease before release", `semver_compare(.rc, "2.0.0")`, IntValue(-1)},
+		{"numeric prerelease", `semver_compare("1.0.0-rc.2", "1.0.0-rc.10")`, IntValue(-1)},
+		{"numeric before alphanumeric", `semver_compare("1.0.0-1", "1.0.0-alpha")`, IntValue(-1)},
+		{"longer prerelease", `semver_compare("1.0.0-alpha.1", "1.0.0-alpha")`, IntValue(1)},
+		{"valid", "semver_valid(.rc)", BoolValue(true)},
+		{"invalid", `semver_valid("1.4")`, BoolValue(false)},
+		{"in condition", `semver_compare(.version, "1.4.0") >= 0`, BoolValue(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestSemverErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing patch", `semver_major("1.4")`, `semver_major: invalid semantic version "1.4": expected major.minor.patch`},
+		{"leading zero", `semver_minor("1.04.0")`, `semver_minor: invalid semantic version "1.04.0": invalid number "04"`},
+		{"not a number", `semver_bump_patch("1.x.0")`, `semver_bump_patch: invalid semantic version "1.x.0": invalid number "x"`},
+		{"empty prerelease", `semver_prerelease("1.0.0-")`, `semver_prerelease: invalid semantic version "1.0.0-": invalid prerelease`},
+		{"prerelease leading zero", `semver_compare("1.0.0-01", "1.0.0")`, `semver_compare: invalid semantic version "1.0.0-01": invalid prerelease`},
+		{"invalid build", `semver_build("1.0.0+a_b")`, `semver_build: invalid semantic version "1.0.0+a_b": invalid build metadata`},
+		{"float from yaml", "semver_major(1.4)", `semver_major: invalid semantic version "1.4": expected major.minor.patch`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, valueResolver(nil))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
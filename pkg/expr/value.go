@@ -0,0 +1,358 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind identifies the type of a Value
+type Kind int
+
+const (
+	KindNull Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindDecimal
+	KindDuration
+	KindQuantity
+	KindTime
+	KindString
+	KindList
+	KindMap
+)
+
+// String returns the name of the kind as used in type errors
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindDecimal:
+		return "decimal"
+	case KindDuration:
+		return "duration"
+	case KindQuantity:
+		return "quantity"
+	case KindTime:
+		return "time"
+	case KindString:
+		return "string"
+	case KindList:
+		return "list"
+	case KindMap:
+		return "map"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// Value is a dynamically typed expression value. The zero Value is null.
+type Value struct {
+	kind Kind
+	b    bool
+	i    int64
+	f    float64
+	d    *big.Rat
+	t    time.Time
+	s    string
+	list []Value
+	m    map[string]Value
+}
+
+// NullValue returns the null value
+func NullValue() Value {
+	return Value{}
+}
+
+// BoolValue returns a bool value
+func BoolValue(b bool) Value {
+	return Value{kind: KindBool, b: b}
+}
+
+// IntValue returns an integer value
+func IntValue(i int64) Value {
+	return Value{kind: KindInt, i: i}
+}
+
+// FloatValue returns a floating-point value
+func FloatValue(f float64) Value {
+	return Value{kind: KindFloat, f: f}
+}
+
+// StringValue returns a string value
+func StringValue(s string) Value {
+	return Value{kind: KindString, s: s}
+}
+
+// ListValue returns a list value
+func ListValue(items []Value) Value {
+	return Value{kind: KindList, list: items}
+}
+
+// MapValue returns a map value
+func MapValue(m map[string]Value) Value {
+	return Value{kind: KindMap, m: m}
+}
+
+// ValueOf converts a Go value as produced by YAML decoding into a Value.
+// Unknown types are converted to their string representation.
+func ValueOf(v interface{}) Value {
+	switch v := v.(type) {
+	case nil:
+		return NullValue()
+	case Value:
+		return v
+	case bool:
+		return BoolValue(v)
+	case int:
+		return IntValue(int64(v))
+	case int64:
+		return IntValue(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			// Exact rather than rounded to a float
+			return DecimalValue(new(big.Rat).SetFrac(new(big.Int).SetUint64(v), big.NewInt(1)))
+		}
+		return IntValue(int64(v))
+	case float64:
+		return FloatValue(v)
+	case string:
+		return StringValue(v)
+	case time.Time:
+		return TimeValue(v)
+	case []interface{}:
+		items := make([]Value, len(v))
+		for i, item := range v {
+			items[i] = ValueOf(item)
+		}
+		return ListValue(items)
+	case map[string]interface{}:
+		m := make(map[string]Value, len(v))
+		for k, item := range v {
+			m[k] = ValueOf(item)
+		}
+		return MapValue(m)
+	case map[interface{}]interface{}:
+		m := make(map[string]Value, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = ValueOf(item)
+		}
+		return MapValue(m)
+	default:
+		return StringValue(fmt.Sprint(v))
+	}
+}
+
+// Kind returns the type of the value
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// IsNull reports whether the value is null
+func (v Value) IsNull() bool {
+	return v.kind == KindNull
+}
+
+// Bool returns the value of a bool; false for other kinds
+func (v Value) Bool() bool {
+	return v.b
+}
+
+// Int returns the value of an int, or a float or decimal truncated towards zero
+func (v Value) Int() int64 {
+	switch v.kind {
+	case KindFloat:
+		return int64(v.f)
+	case KindDecimal:
+		return new(big.Int).Quo(v.d.Num(), v.d.Denom()).Int64()
+	}
+	return v.i
+}
+
+// Float returns the value of a number as float64; 0 for other kinds
+func (v Value) Float() float64 {
+	switch v.kind {
+	case KindInt:
+		return float64(v.i)
+	case KindDecimal:
+		f, _ := v.d.Float64()
+		return f
+	}
+	return v.f
+}
+
+// Decimal returns the value of a number as an exact rational; floats are
+// converted via their shortest decimal representation. The result must not
+// be modified.
+func (v Value) Decimal() *big.Rat {
+	return v.rat()
+}
+
+// List returns the items of a list; nil for other kinds
+func (v Value) List() []Value {
+	return v.list
+}
+
+// Map returns the entries of a map; nil for other kinds
+func (v Value) Map() map[string]Value {
+	return v.m
+}
+
+// String formats the value as placeholder output: numbers without unnecessary
+// decimals, strings as-is, lists as [a b] and maps as map[k:v] with sorted keys
+func (v Value) String() string {
+	switch v.kind {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return strconv.FormatBool(v.b)
+	case KindInt:
+		return strconv.FormatInt(v.i, 10)
+	case KindFloat:
+		return formatFloat(v.f)
+	case KindDecimal:
+		return formatDecimal(v.d)
+	case KindDuration:
+		return time.Duration(v.i).String()
+	case KindQuantity:
+		return formatQuantity(v.d, v.s)
+	case KindTime:
+		if v.b {
+			return v.t.Format(time.DateOnly)
+		}
+		return v.t.Format(time.RFC3339Nano)
+	case KindString:
+		return v.s
+	case KindList:
+		parts := make([]string, len(v.list))
+		for i, item := range v.list {
+			parts[i] = item.String()
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	case KindMap:
+		keys := make([]string, 0, len(v.m))
+		for k := range v.m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ":" + v.m[k].String()
+		}
+		return "map[" + strings.Join(parts, " ") + "]"
+	default:
+		return ""
+	}
+}
+
+// Interface converts the value back to plain Go types
+func (v Value) Interface() interface{} {
+	switch v.kind {
+	case KindBool:
+		return v.b
+	case KindInt:
+		return v.i
+	case KindFloat, KindDecimal:
+		return v.Float()
+	case KindDuration, KindQuantity:
+		return v.String()
+	case KindTime:
+		return v.t
+	case KindString:
+		return v.s
+	case KindList:
+		items := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			items[i] = item.Interface()
+		}
+		return items
+	case KindMap:
+		m := make(map[string]interface{}, len(v.m))
+		for k, item := range v.m {
+			m[k] = item.Interface()
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// describe formats the value with its kind for error messages
+func (v Value) describe() string {
+	if v.kind == KindString {
+		return fmt.Sprintf("string %q", v.s)
+	}
+	return fmt.Sprintf("%s %s", v.kind, v.String())
+}
+
+// numeric returns the value as an int, float or decimal. Numeric strings in
+// any literal form (see parseNumeric) are converted so that environment
+// variables and quoted YAML numbers work in arithmetic.
+func (v Value) numeric() (Value, bool) {
+	switch v.kind {
+	case KindInt, KindFloat, KindDecimal:
+		return v, true
+	case KindString:
+		if n, err := parseNumeric(strings.TrimSpace(v.s)); err == nil {
+			return n, true
+		}
+	}
+	return Value{}, false
+}
+
+// toFloat converts a numeric value to float64
+func (v Value) toFloat() (float64, error) {
+	n, ok := v.numeric()
+	if !ok {
+		return 0, fmt.Errorf("type error: expected a number, got %s", v.describe())
+	}
+	return n.Float(), nil
+}
+
+// toInt converts a numeric value without fractional part to int
+func (v Value) toInt() (int, error) {
+	n, ok := v.numeric()
+	if !ok {
+		return 0, fmt.Errorf("type error: expected an integer, got %s", v.describe())
+	}
+	if n.kind == KindInt && n.i >= math.MinInt32 && n.i <= math.MaxInt32 {
+		return int(n.i), nil
+	}
+	if n.kind == KindFloat && n.f == math.Trunc(n.f) && n.f >= math.MinInt32 && n.f <= math.MaxInt32 {
+		return int(n.f), nil
+	}
+	if n.kind == KindDecimal && n.d.IsInt() && n.d.Num().IsInt64() && n.Int() >= math.MinInt32 && n.Int() <= math.MaxInt32 {
+		return int(n.Int()), nil
+	}
+	return 0, fmt.Errorf("type error: expected an integer, got %s", v.describe())
+}
+
+// truth returns the value of a condition: a bool, or the string "true" or
+// "false" as read from environment variables and quoted YAML
+func (v Value) truth() (bool, error) {
+	switch {
+	case v.kind == KindBool:
+		return v.b, nil
+	case v.kind == KindString && v.s == "true":
+		return true, nil
+	case v.kind == KindString && v.s == "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("type error: expected a bool, got %s", v.describe())
+}
+
+// typeError reports an operator applied to operands of unsupported types
+func typeError(op string, left, right Value) error {
+	return fmt.Errorf("type error: cannot apply %s to %s and %s", op, left.describe(), right.describe())
+}
@@ -0,0 +1,207 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValueOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		kind  Kind
+		str   string
+	}{
+		{"nil", nil, KindNull, "null"},
+		{"bool", true, KindBool, "true"},
+		{"int", 42, KindInt, "42"},
+		{"int64", int64(-7), KindInt, "-7"},
+		{"uint64", uint64(7), KindInt, "7"},
+		{"uint64 above int64", uint64(12345678901234567890), KindDecimal, "12345678901234567890"},
+		{"float", 2.5, KindFloat, "2.5"},
+		{"whole float", 3.0, KindFloat, "3"},
+		{"string", "hi", KindString, "hi"},
+		{"list", []interface{}{"a", 1, true}, KindList, "[a 1 true]"},
+		{"map", map[string]interface{}{"b": 2, "a": 1}, KindMap, "map[a:1 b:2]"},
+		{"interface map", map[interface{}]interface{}{"k": "v"}, KindMap, "map[k:v]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := ValueOf(tt.input)
+			if v.Kind() != tt.kind {
+				t.Errorf("got kind %s, want %s", v.Kind(), tt.kind)
+			}
+			if got := v.String(); got != tt.str {
+				t.Errorf("got %q, want %q", got, tt.str)
+			}
+		})
+	}
+}
+
+func TestValue_Interface(t *testing.T) {
+	input := map[string]interface{}{
+		"list": []interface{}{"a", int64(1), 2.5, true, nil},
+	}
+	if got := ValueOf(input).Interface(); !reflect.DeepEqual(got, input) {
+		t.Errorf("got %#v, want %#v", got, input)
+	}
+}
+
+func TestEvalValue_Types(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".count":   3,
+		".ratio":   0.5,
+		".port":    "8080",
+		".name":    "api",
+		".enabled": true,
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"int plus int stays int", ".count + .count", IntValue(6)},
+		{"int times int stays int", ".count * .count", IntValue(9)},
+		{"int minus int literal", ".count - 5", IntValue(-2)},
+		{"int minus float literal", ".count - 5.0", FloatValue(-2)},
+		{"int plus float", ".count + .ratio", FloatValue(3.5)},
+		{"int division is float", ".count / 2", FloatValue(1.5)},
+		{"numeric string", ".port + .count", IntValue(8083)},
+		{"string reference", ".name", StringValue("api")},
+		{"bool reference", ".enabled", BoolValue(true)},
+		{"string literal", `"x"`, StringValue("x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %s %v, want %s %v", got.Kind(), got, tt.want.Kind(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalValue_TypeErrors(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".name":    "api",
+		".enabled": true,
+		".tags":    []interface{}{"a"},
+		".nothing": nil,
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"string plus number", ".name + 1", `type error: cannot apply + to string "api" and int 1`},
+		{"bool times number", ".enabled * 2", "type error: cannot apply * to bool true and int 2"},
+		{"list minus number", ".tags - 1", "type error: cannot apply - to list [a] and int 1"},
+		{"null divided", ".nothing / 1", "type error: cannot apply / to null null and int 1"},
+		{"string literal operand", `1 + "a"`, `type error: cannot apply + to int 1 and string "a"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAndEvalValue(tt.input, resolver)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_NonNumericResult(t *testing.T) {
+	_, err := ParseAndEval(`"text"`, testResolver(nil))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if want := `type error: expected a number, got string "text"`; err.Error() != want {
+		t.Errorf("got error %q, want %q", err.Error(), want)
+	}
+}
+
+func TestEvalValue_ExactIntegers(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".id":    int64(9007199254740993), // 2^53 + 1
+		".bytes": int64(1 << 40),
+		".nanos": int64(1700000000123456789),
+	})
+
+	tests := []struct {
+		name  string
+		input string
+		want  Value
+	}{
+		{"beyond 2^53", ".id + 1", IntValue(9007199254740994)},
+		{"large literal", "9223372036854775807 - 1", IntValue(9223372036854775806)},
+		{"large product", ".bytes * 1024", IntValue(1 << 50)},
+		{"integer division and modulo", ".nanos // 1000000000 * 1000000000 + .nanos % 1000000000", IntValue(1700000000123456789)},
+		{"exact division stays int", ".bytes / 1024", IntValue(1 << 30)},
+		{"division with remainder", ".id / 2", FloatValue(4503599627370496.5)},
+		{"minimum int", "-9223372036854775807 - 1", IntValue(-9223372036854775808)},
+		{"power", "2 ** 62", IntValue(1 << 62)},
+		{"float literal", "3.0 * 2", FloatValue(6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != tt.want.Kind() || got.String() != tt.want.String() {
+				t.Errorf("got %s, want %s", got.describe(), tt.want.describe())
+			}
+		})
+	}
+}
+
+func TestEvalValue_IntegerOverflow(t *testing.T) {
+	resolver := valueResolver(map[string]interface{}{
+		".max": int64(9223372036854775807),
+		".min": int64(-9223372036854775808),
+	})
+
+	// Results beyond int64 continue as exact decimals
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"addition", ".max + 1", "9223372036854775808"},
+		{"subtraction", ".min - 1", "-9223372036854775809"},
+		{"multiplication", ".max * 2", "18446744073709551614"},
+		{"min times minus one", ".min * -1", "9223372036854775808"},
+		{"negation", "-.min", "9223372036854775808"},
+		{"abs", "abs(.min)", "9223372036854775808"},
+		{"power", "2 ** 64", "18446744073709551616"},
+		{"integer division", ".min // -1", "9223372036854775808"},
+		{"division", ".min / -1", "9223372036854775808"},
+		{"large product", "100000000 * 100000000000", "10000000000000000000"},
+		{"literal", "9223372036854775808", "9223372036854775808"},
+		{"hex literal", "0x1_0000_0000_0000_0000", "18446744073709551616"},
+		{"continued arithmetic", "(.max + 1) / 2", "4611686018427387904"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndEvalValue(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Kind() != KindDecimal || got.String() != tt.want {
+				t.Errorf("got %s, want decimal %s", got.describe(), tt.want)
+			}
+		})
+	}
+}
@@ -1,54 +1,295 @@
 package substitutor
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/huberp/yamlsubst/pkg/expr"
 	"gopkg.in/yaml.v3"
 )
 
-// placeholderRegex is compiled once for better performance
-// Updated to match any content inside ${...}
-var placeholderRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+// Substitutor replaces placeholders with configurable expression evaluation.
+// The zero Substitutor behaves like the package-level functions.
+type Substitutor struct {
+	// Evaluator evaluates placeholder expressions, e.g. with decimal arithmetic
+	Evaluator expr.Evaluator
+	// FloatFormat, if set, formats float and decimal results of placeholders
+	// without their own format directive, e.g. "%.2f" (see expr.Format)
+	FloatFormat string
+	// Rendered, if set, is called by SubstituteData for every placeholder with
+	// its expression (without ${ and }) and the text it was replaced with; a
+	// placeholder that cannot be resolved is kept as-is
+	Rendered func(expression, text string)
+	// Invalid, if set, is called for every placeholder that cannot be parsed,
+	// e.g. because of an unknown filter or a wrong number of filter
+	// arguments, with its expression and the parse error; the placeholder is
+	// kept as-is
+	Invalid func(expression string, err error)
+}
 
 // Substitute replaces placeholders in the input string with values from the YAML content.
 // Placeholders are in the format ${expression} where expression can be:
 // - A simple YAML reference: ${.path.to.value}
 // - An environment variable: ${$VAR}
 // - An arithmetic expression: ${.width * .height}, ${$PORT + 1000}, ${.base + $OFFSET}
+// - A function call: ${upper(.name)}, ${substr(.id, 0, 8)}
+// - Any of the above followed by filters: ${.name | upper}, ${.host | default "localhost"}
 func Substitute(input, yamlContent string) (string, error) {
-	// Parse YAML content
+	return (&Substitutor{}).Substitute(input, yamlContent)
+}
+
+// Substitute is like the package-level Substitute, using s's evaluator
+func (s *Substitutor) Substitute(input, yamlContent string) (string, error) {
+	data, err := ParseValues(yamlContent)
+	if err != nil {
+		return "", err
+	}
+
+	return s.SubstituteData(input, data), nil
+}
+
+// ParseValues parses YAML content into the data structure used by SubstituteData.
+// Parsing once and reusing the result avoids repeated YAML decoding when the same
+// values are applied to many templates.
+func ParseValues(yamlContent string) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlContent), &node); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
 	var data interface{}
-	if err := yaml.Unmarshal([]byte(yamlContent), &data); err != nil {
-		return "", fmt.Errorf("failed to parse YAML: %w", err)
+	if err := node.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return markDates(&node, data), nil
+}
+
+// markDates replaces the timestamps in data that were written as plain dates
+// in node (e.g. released: 2024-03-05) with date values, so that they render
+// as dates rather than as midnight UTC
+func markDates(node *yaml.Node, data interface{}) interface{} {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 1 {
+			return markDates(node.Content[0], data)
+		}
+	case yaml.AliasNode:
+		return markDates(node.Alias, data)
+	case yaml.ScalarNode:
+		if t, ok := data.(time.Time); ok {
+			if _, err := time.Parse(time.DateOnly, node.Value); err == nil {
+				return expr.DateValue(t)
+			}
+		}
+	case yaml.SequenceNode:
+		if items, ok := data.([]interface{}); ok && len(items) == len(node.Content) {
+			for i, item := range items {
+				items[i] = markDates(node.Content[i], item)
+			}
+		}
+	case yaml.MappingNode:
+		if m, ok := data.(map[string]interface{}); ok {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if v, ok := m[node.Content[i].Value]; ok {
+					m[node.Content[i].Value] = markDates(node.Content[i+1], v)
+				}
+			}
+		}
 	}
+	return data
+}
 
-	result := placeholderRegex.ReplaceAllStringFunc(input, func(match string) string {
+// SubstituteData replaces placeholders in the input string with values from already
+// parsed YAML data (see ParseValues). Placeholders that cannot be resolved are left as-is.
+func SubstituteData(input string, data interface{}) string {
+	return (&Substitutor{}).SubstituteData(input, data)
+}
+
+// SubstituteData is like the package-level SubstituteData, using s's evaluator.
+// Without a Generator, the random functions get a new one for each input, so
+// that named calls share their value within the input.
+func (s *Substitutor) SubstituteData(input string, data interface{}) string {
+	if s.Evaluator.Generator == nil {
+		withGenerator := *s
+		withGenerator.Evaluator.Generator = expr.NewGenerator()
+		s = &withGenerator
+	}
+
+	var sb strings.Builder
+	last := 0
+	scanPlaceholders(input, func(start, end int) {
 		// Extract the expression (remove ${ and })
-		expression := match[2 : len(match)-1] // Remove ${ and }
+		expression := input[start+2 : end-1]
 
 		// Try to evaluate as expression
-		value, err := evaluateExpression(expression, data)
+		value, err := s.evaluate(expression, data)
 		if err != nil {
 			// If evaluation fails, keep the placeholder as-is
-			return match
+			if s.Rendered != nil {
+				s.Rendered(expression, input[start:end])
+			}
+			return
+		}
+		if s.Rendered != nil {
+			s.Rendered(expression, value)
+		}
+
+		if last == 0 {
+			sb.Grow(len(input))
+		}
+		sb.WriteString(input[last:start])
+		sb.WriteString(value)
+		last = end
+	})
+
+	if last == 0 {
+		return input
+	}
+	sb.WriteString(input[last:])
+	return sb.String()
+}
+
+// scanPlaceholders calls visit with the bounds of every non-empty ${...}
+// placeholder in input, where end is the index after the closing brace.
+// A closing brace inside a double-quoted string does not end the placeholder.
+func scanPlaceholders(input string, visit func(start, end int)) {
+	pos := 0
+	for {
+		i := strings.Index(input[pos:], "${")
+		if i < 0 {
+			return
+		}
+		start := pos + i
+		closing := placeholderEnd(input, start+2)
+		if closing < 0 {
+			return
+		}
+		if closing == start+2 {
+			// Empty placeholder
+			pos = closing
+			continue
+		}
+		visit(start, closing+1)
+		pos = closing + 1
+	}
+}
+
+// placeholderEnd returns the index of the brace closing a placeholder whose
+// content starts at from, or -1 if there is none
+func placeholderEnd(input string, from int) int {
+	inQuote := false
+	for j := from; j < len(input); j++ {
+		switch input[j] {
+		case '\\':
+			if inQuote {
+				j++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '}':
+			if !inQuote {
+				return j
+			}
 		}
+	}
+
+	// Unbalanced quote: fall back to the first closing brace
+	if j := strings.IndexByte(input[from:], '}'); j >= 0 {
+		return from + j
+	}
+	return -1
+}
 
-		return value
+// Placeholders returns the distinct placeholder expressions in input, without
+// the surrounding ${ and }, in order of first occurrence.
+func Placeholders(input string) []string {
+	seen := make(map[string]bool)
+	var expressions []string
+	scanPlaceholders(input, func(start, end int) {
+		expression := input[start+2 : end-1]
+		if !seen[expression] {
+			seen[expression] = true
+			expressions = append(expressions, expression)
+		}
 	})
+	return expressions
+}
+
+// Evaluate evaluates a single placeholder expression (without ${ and }) against
+// parsed YAML data and returns the text it would be replaced with.
+func Evaluate(expression string, data interface{}) (string, error) {
+	return (&Substitutor{}).Evaluate(expression, data)
+}
+
+// Evaluate is like the package-level Evaluate, using s's evaluator
+func (s *Substitutor) Evaluate(expression string, data interface{}) (string, error) {
+	return s.evaluate(expression, data)
+}
 
-	return result, nil
+// Lookup returns the value at the given dot-separated path in parsed YAML data,
+// or nil if the path does not exist.
+func Lookup(data interface{}, path string) interface{} {
+	return navigate(data, path)
 }
 
-// evaluateExpression evaluates an expression which can be a simple reference or arithmetic expression
-func evaluateExpression(expression string, yamlData interface{}) (string, error) {
-	// Create a resolver function that can handle both YAML refs and env vars
-	resolver := func(ref string) (float64, error) {
+// evaluate evaluates an expression which can be a simple reference, a function
+// call or an arithmetic expression, optionally followed by filters
+func (s *Substitutor) evaluate(expression string, yamlData interface{}) (string, error) {
+	ph, err := expr.ParsePlaceholder(expression)
+	if err != nil {
+		text, err := lookupFallback(expression, yamlData, err)
+		if err != nil && s.Invalid != nil {
+			s.Invalid(expression, err)
+		}
+		return text, err
+	}
+
+	resolver := newResolver(yamlData)
+	value, err := s.Evaluator.Eval(ph.Expr, resolver)
+	if len(ph.Filters) > 0 {
+		return s.applyFilters(ph, value, err, resolver)
+	}
+	if err != nil {
+		return lookupFallback(expression, yamlData, err)
+	}
+
+	return s.format(ph, value)
+}
+
+// format renders a placeholder's value with its format directive or, for
+// floats and decimals, the default FloatFormat
+func (s *Substitutor) format(ph *expr.Placeholder, value expr.Value) (string, error) {
+	switch {
+	case ph.Format != "":
+		return expr.Format(value, ph.Format)
+	case s.FloatFormat != "" && (value.Kind() == expr.KindFloat || value.Kind() == expr.KindDecimal):
+		return expr.Format(value, s.FloatFormat)
+	default:
+		return value.String(), nil
+	}
+}
+
+// lookupFallback handles expressions that are not valid expressions: it might be
+// a simple reference to a key the expression grammar cannot express (e.g. .my-key)
+func lookupFallback(expression string, yamlData interface{}, err error) (string, error) {
+	if expression[0] == '.' {
+		value := navigate(yamlData, expression)
+		if value != nil {
+			return valueToString(value), nil
+		}
+	}
+	return "", err
+}
+
+// newResolver creates a resolver function that can handle both YAML refs and env vars.
+// YAML values keep their type; env vars are strings, which arithmetic accepts if numeric.
+func newResolver(yamlData interface{}) expr.ValueResolver {
+	return func(ref string) (expr.Value, error) {
 		if len(ref) == 0 {
-			return 0, fmt.Errorf("empty reference")
+			return expr.Value{}, fmt.Errorf("empty reference")
 		}
 
 		switch ref[0] {
@@ -56,55 +297,52 @@ func evaluateExpression(expression string, yamlData interface{}) (string, error)
 			// YAML reference
 			value := navigate(yamlData, ref)
 			if value == nil {
-				return 0, fmt.Errorf("reference not found: %s", ref)
+				return expr.Value{}, fmt.Errorf("reference %w: %s", expr.ErrNotFound, ref)
 			}
-			return valueToFloat(value)
+			return expr.ValueOf(value), nil
 		case '$':
 			// Environment variable
 			envVar := ref[1:] // Remove $
 			envValue := os.Getenv(envVar)
 			if envValue == "" {
-				return 0, fmt.Errorf("env var not found: %s", envVar)
+				return expr.Value{}, fmt.Errorf("env var %w: %s", expr.ErrNotFound, envVar)
 			}
-			return strconv.ParseFloat(envValue, 64)
+			return expr.StringValue(envValue), nil
 		}
 
-		return 0, fmt.Errorf("invalid reference: %s", ref)
+		return expr.Value{}, fmt.Errorf("invalid reference: %s", ref)
 	}
+}
 
-	// Try to parse and evaluate as expression
-	result, err := expr.ParseAndEval(expression, resolver)
-	if err != nil {
-		// If it's not a valid expression, it might be a simple string reference
-		// Try to navigate directly (for backward compatibility with non-numeric values)
-		if expression[0] == '.' {
-			value := navigate(yamlData, expression)
-			if value != nil {
-				return valueToString(value), nil
+// applyFilters pipes an evaluated placeholder value through its filters. An
+// expression referencing a missing value (evalErr wraps expr.ErrNotFound) is
+// passed on as a missing value so that filters like default can replace it;
+// any other evaluation error is returned.
+func (s *Substitutor) applyFilters(ph *expr.Placeholder, value expr.Value, evalErr error, resolver expr.ValueResolver) (string, error) {
+	if errors.Is(evalErr, expr.ErrNotFound) {
+		value = expr.NullValue()
+	} else if evalErr != nil {
+		return "", evalErr
+	}
+
+	for _, f := range ph.Filters {
+		args := make([]expr.Value, len(f.Args))
+		for i, arg := range f.Args {
+			var err error
+			if args[i], err = s.Evaluator.Eval(arg, resolver); err != nil {
+				return "", err
 			}
 		}
-		return "", err
+		var err error
+		if value, err = s.Evaluator.ApplyFilter(f.Name, value, args); err != nil {
+			return "", err
+		}
 	}
 
-	// Format the numeric result
-	return expr.FormatResult(result), nil
-}
-
-// valueToFloat converts a value to float64 for expression evaluation
-func valueToFloat(value interface{}) (float64, error) {
-	switch v := value.(type) {
-	case int:
-		return float64(v), nil
-	case int64:
-		return float64(v), nil
-	case float64:
-		return v, nil
-	case string:
-		// Try to parse string as float
-		return strconv.ParseFloat(v, 64)
-	default:
-		return 0, fmt.Errorf("cannot convert to float: %v", value)
+	if value.IsNull() {
+		return "", fmt.Errorf("no value for: %s", ph.String())
 	}
+	return s.format(ph, value)
 }
 
 // navigate traverses the YAML data structure using the given path
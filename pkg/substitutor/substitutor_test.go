@@ -1,7 +1,11 @@
 package substitutor
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/huberp/yamlsubst/pkg/expr"
 )
 
 func TestSubstitute_SimpleValue(t *testing.T) {
@@ -306,3 +310,585 @@ value: 10
 		t.Errorf("expected %q, got %q", expected, result)
 	}
 }
+
+func TestSubstituteData_ReusesParsedValues(t *testing.T) {
+	data, err := ParseValues(`
+name: John
+port: 8080
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Hello ${.name}", "Hello John"},
+		{"Port ${.port + 1}", "Port 8081"},
+		{"Missing ${.missing}", "Missing ${.missing}"},
+	}
+
+	for _, tt := range tests {
+		if result := SubstituteData(tt.input, data); result != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, result)
+		}
+	}
+}
+
+func TestParseValues_InvalidYAML(t *testing.T) {
+	if _, err := ParseValues("invalid: yaml: content:"); err == nil {
+		t.Fatal("expected error for invalid YAML, got nil")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	data, err := ParseValues(`
+services:
+  - name: api
+  - name: web
+app:
+  name: MyApp
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := Lookup(data, ".app.name"); got != "MyApp" {
+		t.Errorf("expected %q, got %v", "MyApp", got)
+	}
+	if got, ok := Lookup(data, ".services").([]interface{}); !ok || len(got) != 2 {
+		t.Errorf("expected list of 2 services, got %v", Lookup(data, ".services"))
+	}
+	if got := Lookup(data, ".missing"); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	input := "${.a} and ${.b * 2} and ${.a} again, $notplaceholder"
+	expected := []string{".a", ".b * 2"}
+
+	result := Placeholders(input)
+	if len(result) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("expected %q at %d, got %q", expected[i], i, result[i])
+		}
+	}
+}
+
+func TestSubstitutor_Rendered(t *testing.T) {
+	data, err := ParseValues("name: John")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rendered []string
+	s := &Substitutor{Rendered: func(expression, text string) {
+		rendered = append(rendered, expression+"="+text)
+	}}
+	result := s.SubstituteData("${.name} ${.missing} ${random_string(8)}", data)
+
+	expected := []string{".name=John", ".missing=${.missing}", "random_string(8)=" + strings.Fields(result)[2]}
+	if strings.Join(rendered, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, rendered)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	data, err := ParseValues(`
+name: John
+width: 5
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, err := Evaluate(".name", data); err != nil || got != "John" {
+		t.Errorf("expected %q, got %q (err: %v)", "John", got, err)
+	}
+	if got, err := Evaluate(".width * 2", data); err != nil || got != "10" {
+		t.Errorf("expected %q, got %q (err: %v)", "10", got, err)
+	}
+	if _, err := Evaluate(".missing", data); err == nil {
+		t.Error("expected error for missing reference, got nil")
+	}
+}
+
+func TestSubstitute_Filters(t *testing.T) {
+	yamlContent := `
+name: "  John  "
+host: db.internal
+tags: [web, api, v2]
+port: 8080
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.name | trim | upper}", "JOHN"},
+		{`${.host | default "localhost"}`, "db.internal"},
+		{`${.missing | default "localhost"}`, "localhost"},
+		{`${.missing.deep | default .host}`, "db.internal"},
+		{`${.tags | join ","}`, "web,api,v2"},
+		{`${.tags | join "}"}`, "web}api}v2"},
+		{`${.port + 1 | default 0}`, "8081"},
+		{`${.missing + 1 | default 0}`, "0"},
+		{`${.missing | default -1}`, "-1"},
+		{`${.host | replace "." "-"}`, "db-internal"},
+	})
+}
+
+func TestSubstitute_FilterErrorsKeepPlaceholder(t *testing.T) {
+	yamlContent := `
+name: John
+count: 3
+`
+	tests := []string{
+		"${.name | shout}",
+		"${.missing | upper}",
+		`${.name | upper "x"}`,
+		`${.name | join ","}`,
+		`${.missing | default .also_missing}`,
+		"${.count / 0 | default 5}",
+		`${.name + 1 | default "x"}`,
+		"${.count / 0 | upper}",
+	}
+
+	var invalid []string
+	s := &Substitutor{Invalid: func(expression string, err error) {
+		invalid = append(invalid, expression+": "+err.Error())
+	}}
+	for _, input := range tests {
+		result, err := s.Substitute(input, yamlContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != input {
+			t.Errorf("expected %q, got %q", input, result)
+		}
+	}
+
+	// Only placeholders that do not parse are reported, not evaluation errors
+	want := []string{
+		".name | shout: unknown filter: shout",
+		`.name | upper "x": filter upper expects 0 arguments, got 1`,
+	}
+	if strings.Join(invalid, "\n") != strings.Join(want, "\n") {
+		t.Errorf("reported %q, want %q", invalid, want)
+	}
+}
+
+func TestSubstitute_PlaceholderScanning(t *testing.T) {
+	yamlContent := `
+name: John
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${}", "${}"},
+		{"${.name", "${.name"},
+		{"a ${.name} b ${.name}", "a John b John"},
+		{"${.name}${.name}", "JohnJohn"},
+		{`${"unbalanced} ${.name}`, `${"unbalanced} John`},
+	})
+}
+
+func TestSubstitute_StringFunctions(t *testing.T) {
+	t.Setenv("TEST_REGION", "eu-west-1")
+	yamlContent := `
+name: my-service
+commit: 3f9a2c1d7e
+version: "08"
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${upper(.name)}", "MY-SERVICE"},
+		{`${replace(.name, "-", "_")}`, "my_service"},
+		{"${substr(.commit, 0, 7)}", "3f9a2c1"},
+		{`${pad_left(.version, 4, "0")}`, "0008"},
+		{`${starts_with(.name, "my-")}`, "true"},
+		{`${contains($TEST_REGION, "eu")}`, "true"},
+		{"${$TEST_REGION}", "eu-west-1"},
+		{"${.version}", "08"},
+		{`${split(.name, "-") | join "."}`, "my.service"},
+		{"${.name | substr 3}", "service"},
+	})
+}
+
+func TestSubstitute_Operators(t *testing.T) {
+	t.Setenv("TEST_DEBUG", "true")
+	yamlContent := `
+replicas: 3
+env: prod
+tls: false
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{`${.replicas > 1 ? "ha" : "single"}`, "ha"},
+		{`${.replicas == 3}`, "true"},
+		{`${.env != "prod"}`, "false"},
+		{`${.env == "prod" && !.tls}`, "true"},
+		{`${$TEST_DEBUG || .tls}`, "true"},
+		{`${.region ?? "eu-west-1"}`, "eu-west-1"},
+		{`${$TEST_MISSING_VAR ?? .env}`, "prod"},
+		{`${.tls ? .port : 80}`, "80"},
+		{`${.tls ? "https" : "http"}://host`, "http://host"},
+		{`${.tls == false}`, "true"},
+		{`${.missing ?? false}`, "false"},
+		{`${.replicas ?? null}`, "3"},
+		{`${.env < 1}`, `${.env < 1}`},
+	})
+}
+
+func TestSubstitute_ArithmeticOperators(t *testing.T) {
+	yamlContent := `
+offset: 30
+total: 17
+pages: 5
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${-.offset}", "-30"},
+		{"${.total * -1}", "-17"},
+		{"${.total % .pages}", "2"},
+		{"${.total // .pages}", "3"},
+		{"${2 ** 10}", "1024"},
+		{"${.total % 0}", "${.total % 0}"},
+	})
+}
+
+func TestSubstitute_BitwiseOperators(t *testing.T) {
+	yamlContent := `
+mode: 0o644
+flags: 5
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.mode & 0o077}", "36"},
+		{"${(.flags | 2) :%b}", "111"},
+		{"${.flags ^ 1}", "4"},
+		{"${1 << .flags}", "32"},
+		{"${.flags | upper}", "5"},
+		{"${.flags & 2 == 0 ? \"off\" : \"on\"}", "off"},
+		{"${1.5 & 1}", "${1.5 & 1}"},
+	})
+}
+
+func TestSubstitute_Durations(t *testing.T) {
+	yamlContent := `
+timeout: 30s
+interval: 1h30m
+grace: 5m
+tick: 1m
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.timeout * 2}", "1m0s"},
+		{"${duration(.grace) * 1.5}", "7m30s"},
+		{"${.grace * 2}", "${.grace * 2}"},
+		{"${duration(.tick) * 0.5}", "30s"},
+		{"${.grace + 30s}", "5m30s"},
+		{"${.timeout + .interval}", "1h30m30s"},
+		{"${seconds(.timeout)}", "30"},
+		{"${millis(.interval)}", "5400000"},
+		{"${.timeout | seconds}", "30"},
+		{"${.timeout > 1m ? \"slow\" : \"fast\"}", "fast"},
+		{"${.timeout + 1}", "${.timeout + 1}"},
+	})
+}
+
+func TestSubstitute_Quantities(t *testing.T) {
+	yamlContent := `
+resources:
+  memory: 512Mi
+  cpu: 500m
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.resources.memory * 0.75}", "384Mi"},
+		{"-Xmx${to_unit(.resources.memory * 0.75, \"Mi\")}m", "-Xmx384m"},
+		{"${bytes(.resources.memory)}", "536870912"},
+		{"${quantity(.resources.cpu) * 2}", "1000m"},
+		{"${.resources.cpu * 2}", "${.resources.cpu * 2}"},
+		{"${.resources.memory | bytes}", "536870912"},
+		{"${.resources.memory + 1}", "${.resources.memory + 1}"},
+	})
+}
+
+func TestSubstitutor_Now(t *testing.T) {
+	s := &Substitutor{Evaluator: expr.Evaluator{Now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}}
+	yamlContent := `
+released: 2024-03-05
+updated: 2024-03-05T10:30:00Z
+first: &first 2022-06-01
+history: [2023-01-10, *first]
+`
+	runSubstituteCases(t, s, yamlContent, []substituteCase{
+		{"${now()}", "2025-01-01T12:00:00Z"},
+		{"${.released}", "2024-03-05"},
+		{"${.updated}", "2024-03-05T10:30:00Z"},
+		{"${.history | join \",\"}", "2023-01-10,2022-06-01"},
+		{"${.released < .updated}", "true"},
+		{"${date(\"%Y-%m-%d\")}", "2025-01-01"},
+		{"${unix()}", "1735732800"},
+		{"${.released | date \"Jan 2, 2006\"}", "Mar 5, 2024"},
+		{"${add_duration(now(), 24h) | date \"DateOnly\"}", "2025-01-02"},
+		{"${date(\"%Q\")}", "${date(\"%Q\")}"},
+	})
+}
+
+func TestSubstitute_SemverFunctions(t *testing.T) {
+	yamlContent := `
+app:
+  version: 1.4.2
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${semver_major(.app.version)}", "1"},
+		{"${.app.version | semver_bump_minor}", "1.5.0"},
+		{"${semver_compare(.app.version, \"1.10.0\") < 0 ? \"old\" : \"new\"}", "old"},
+		{"${semver_major(\"1.4\")}", "${semver_major(\"1.4\")}"},
+	})
+}
+
+func TestSubstitute_NetworkFunctions(t *testing.T) {
+	yamlContent := `
+network:
+  cidr: 10.20.0.0/16
+  gateway: 10.20.0.1
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${cidrsubnet(.network.cidr, 8, 2)}", "10.20.2.0/24"},
+		{"${cidrhost(.network.cidr, 10)}", "10.20.0.10"},
+		{"${cidrnetmask(.network.cidr)}", "255.255.0.0"},
+		{"${.network.gateway | ip_add 1}", "10.20.0.2"},
+		{"${cidrhost(.network.cidr, 70000)}", "${cidrhost(.network.cidr, 70000)}"},
+	})
+}
+
+func TestSubstitute_EncodingFunctions(t *testing.T) {
+	yamlContent := `
+db:
+  password: "p@ss word"
+  replicas: 3
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.db.password | b64enc}", "cEBzcyB3b3Jk"},
+		{"${b64enc(.db.password)}", "cEBzcyB3b3Jk"},
+		{"${.db.password | urlquery}", "p%40ss+word"},
+		{"${.db.password | shell_quote}", "'p@ss word'"},
+		{"${.db.replicas | json_quote}", `"3"`},
+		{"${.db.password | yaml_quote}", `"p@ss word"`},
+		{"${.db.missing | b64enc}", "${.db.missing | b64enc}"},
+	})
+}
+
+func TestSubstitute_HashFunctions(t *testing.T) {
+	yamlContent := `
+name: api
+config:
+  port: 8080
+  host: localhost
+`
+	reordered := `
+name: api
+config: {host: localhost, port: 8080}
+`
+	input := "checksum=${sha256(.config)} id=${uuid_v5(\"dns\", .name)} short=${.name | crc32}"
+
+	first, err := Substitute(input, yamlContent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "checksum=68a1155359f57e2adaf4a076b97ab9b3836b4f6692f0bb989e0662d317339230 id=5513b352-dfe9-5541-91a3-a38db7b6dcb6 short=ad05d80f"
+	if first != expected {
+		t.Errorf("expected %q, got %q", expected, first)
+	}
+
+	second, err := Substitute(input, reordered)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second != first {
+		t.Errorf("hash depends on YAML formatting: %q != %q", second, first)
+	}
+}
+
+func TestSubstitutor_RandomValues(t *testing.T) {
+	yamlContent := `
+db:
+  user: app
+`
+	input := "DB_PASSWORD=${random_string(16, \"alnum\", \"db\")}\nDB_URL=postgres://${.db.user}:${random_string(16, \"alnum\", \"db\")}@localhost\nAPI_KEY=${random_string(16)}\nSESSION_KEY=${random_string(16)}\n"
+
+	render := func(g *expr.Generator) string {
+		s := &Substitutor{Evaluator: expr.Evaluator{Generator: g}}
+		result, err := s.Substitute(input, yamlContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	g := expr.NewSeededGenerator(1)
+	first := render(g)
+	password := g.Values[`random_string(16, "alnum", "db")`].String()
+	if !strings.Contains(first, "DB_PASSWORD="+password+"\n") || !strings.Contains(first, "app:"+password+"@") {
+		t.Errorf("named call did not reuse %q: %q", password, first)
+	}
+	apiKey, sessionKey := g.Values["random_string(16)#1"].String(), g.Values["random_string(16)#2"].String()
+	if apiKey == sessionKey || !strings.Contains(first, "API_KEY="+apiKey) || !strings.Contains(first, "SESSION_KEY="+sessionKey) {
+		t.Errorf("unnamed calls: got %q and %q in %q", apiKey, sessionKey, first)
+	}
+
+	if second := render(expr.NewSeededGenerator(1)); second != first {
+		t.Errorf("same seed rendered %q, want %q", second, first)
+	}
+
+	// Restored values reproduce an earlier run regardless of the seed
+	restored := expr.NewSeededGenerator(2)
+	for call, value := range g.Values {
+		restored.Values[call] = value
+	}
+	if third := render(restored); third != first {
+		t.Errorf("restored values rendered %q, want %q", third, first)
+	}
+
+	// Without a Generator unnamed calls generate new values and named calls
+	// share theirs, like with one
+	result, err := Substitute(`${random_string(16)} ${random_string(16)} ${uuid("id")} ${uuid("id")}`, yamlContent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values := strings.Fields(result); values[0] == values[1] || values[2] != values[3] {
+		t.Errorf("got %q, want two different strings and one shared uuid", result)
+	}
+}
+
+func TestSubstitute_MathFunctions(t *testing.T) {
+	yamlContent := `
+cpus: 4
+memory_mb: 3000
+limits: [8, 2, 16]
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${clamp(round(.memory_mb * 0.75), 256, 4096)}", "2250"},
+		{"${max(.cpus * 2 + 1, 10)}", "10"},
+		{"${min(.limits)}", "2"},
+		{"${.memory_mb / 7 | round 2}", "428.57"},
+		{"${ceil(.cpus / 3)}", "2"},
+		{"${sqrt(-1)}", "${sqrt(-1)}"},
+	})
+}
+
+func TestSubstitute_ExactIntegers(t *testing.T) {
+	yamlContent := `
+id: 9007199254740993
+epoch_ns: 1700000000123456789
+max: 9223372036854775807
+big: 12345678901234567890
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.id}", "9007199254740993"},
+		{"${.id + 1}", "9007199254740994"},
+		{"${.epoch_ns // 1000000000}", "1700000000"},
+		{"${.max + 1}", "9223372036854775808"},
+		{"${100000000 * 100000000000}", "10000000000000000000"},
+		{"${.big}", "12345678901234567890"},
+		{"${.big + 1}", "12345678901234567891"},
+		{"${10 / 4}", "2.5"},
+	})
+}
+
+func TestSubstitutor_Decimal(t *testing.T) {
+	yamlContent := `
+base_price: 19.99
+a: 0.1
+b: 0.2
+`
+	s := &Substitutor{Evaluator: expr.Evaluator{Decimal: &expr.DecimalMode{Scale: 2, Rounding: expr.RoundHalfUp}}}
+	runSubstituteCases(t, s, yamlContent, []substituteCase{
+		{"${.a + .b}", "0.3"},
+		{"${.base_price * 1.15}", "22.99"},
+		{"${.base_price}", "19.99"},
+		{"${1 / 3 | default 0}", "0.33"},
+		{"${.base_price * 1.15 > 22.98}", "true"},
+	})
+
+	// The package-level functions keep floating-point arithmetic
+	if result, _ := Substitute("${.a + .b}", yamlContent); result != "0.30000000000000004" {
+		t.Errorf("expected float result, got %q", result)
+	}
+}
+
+func TestSubstitute_Format(t *testing.T) {
+	yamlContent := `
+price: 19.99
+mode: 493
+ratio: 0.125
+total: 1234567
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${.price * 1.15 :%.2f}", "22.99"},
+		{`${format(.price * 3, "%08.3f")}`, "0059.970"},
+		{"${.mode :%#o}", "0755"},
+		{`${.mode | format "%x"}`, "1ed"},
+		{"${.ratio :%.1p}", "12.5%"},
+		{"${.total :%,d}", "1,234,567"},
+		{"${.missing | default 1.5 :%.2f}", "1.50"},
+		{"${.price :%d}", "${.price :%d}"},
+		{"${.price :%z}", "${.price :%z}"},
+	})
+}
+
+func TestSubstitutor_FloatFormat(t *testing.T) {
+	yamlContent := `
+price: 19.99
+count: 3
+name: api
+big: 12345678901234567890
+`
+	s := &Substitutor{FloatFormat: "%.2f"}
+	runSubstituteCases(t, s, yamlContent, []substituteCase{
+		{"${.price * 1.15}", "22.99"},
+		{"${.price}", "19.99"},
+		{"${.count}", "3"},
+		{"${.count / 2}", "1.50"},
+		{"${.big}", "12345678901234567890.00"},
+		{"${.name}", "api"},
+		{"${.price :%.3f}", "19.990"},
+	})
+}
+
+func TestSubstitute_NumericLiterals(t *testing.T) {
+	t.Setenv("TEST_MASK", "0x0F")
+	yamlContent := `
+max_bytes: "1_000_000"
+mode: "0o644"
+rate: "2.5e-3"
+`
+	runSubstituteCases(t, &Substitutor{}, yamlContent, []substituteCase{
+		{"${1e6}", "1000000"},
+		{"${0x1F + 1}", "32"},
+		{"${0o755 :%#o}", "0755"},
+		{"${0b1010}", "10"},
+		{"${1_000_000 / 1_000}", "1000"},
+		{"${.max_bytes * 2}", "2000000"},
+		{"${.mode + 0}", "420"},
+		{"${.rate * 1000}", "2.5"},
+		{"${$TEST_MASK + 1}", "16"},
+		{"${.mode}", "0o644"},
+	})
+}
+
+// substituteCase is an input template and its expected rendering
+type substituteCase struct {
+	input    string
+	expected string
+}
+
+// runSubstituteCases renders each case's input with s and yamlContent
+func runSubstituteCases(t *testing.T, s *Substitutor, yamlContent string, cases []substituteCase) {
+	t.Helper()
+	for _, tt := range cases {
+		result, err := s.Substitute(tt.input, yamlContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, result)
+		}
+	}
+}
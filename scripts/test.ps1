@@ -80,6 +80,194 @@ app:
         exit 1
     }
 
+    # Rendered files keep the template's line endings; compare them normalized
+    function Read-Rendered([string]$Path) {
+        ((Get-Content -Raw $Path) -replace "`r`n", "`n").TrimEnd()
+    }
+
+    # Test 5: Foreach rendering
+    @"
+env: prod
+services:
+  - name: api
+    port: 80
+  - name: web
+    port: 8080
+"@ | Out-File -FilePath "$TEMP_DIR/services.yaml" -Encoding UTF8
+
+    'name=${.item.name} port=${.item.port} index=${.loop.index} env=${.env}' | Out-File -FilePath "$TEMP_DIR/service.tmpl" -Encoding UTF8
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" `
+        --foreach .services --foreach-output "$TEMP_DIR/out/`${.item.name}.conf"
+    $RESULT = Read-Rendered "$TEMP_DIR/out/web.conf"
+    $EXPECTED = "name=web port=8080 index=1 env=prod"
+
+    if ($RESULT -ne $EXPECTED) {
+        Write-Error "Foreach integration test failed!`nExpected: $EXPECTED`nGot: $RESULT"
+        exit 1
+    }
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" `
+        --foreach .services --output "$TEMP_DIR/out/all.conf"
+    $RESULT = Read-Rendered "$TEMP_DIR/out/all.conf"
+    $EXPECTED = "name=api port=80 index=0 env=prod`nname=web port=8080 index=1 env=prod"
+
+    if ($RESULT -ne $EXPECTED) {
+        Write-Error "Foreach with --output integration test failed!`nExpected: $EXPECTED`nGot: $RESULT"
+        exit 1
+    }
+
+    # Test 6: Directory rendering (file modes are not checked on Windows)
+    New-Item -ItemType Directory -Path "$TEMP_DIR/templates/bin" | Out-Null
+    'env=${.env}' | Out-File -FilePath "$TEMP_DIR/templates/app.conf.tmpl" -Encoding UTF8
+    'raw ${.env}' | Out-File -FilePath "$TEMP_DIR/templates/README.txt" -Encoding UTF8
+    'echo ${.env}' | Out-File -FilePath "$TEMP_DIR/templates/bin/run.sh.tmpl" -Encoding UTF8
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --input-dir "$TEMP_DIR/templates" `
+        --output-dir "$TEMP_DIR/rendered" --include '*.tmpl' --strip-suffix .tmpl
+
+    if ((Read-Rendered "$TEMP_DIR/rendered/app.conf") -ne "env=prod" -or
+        (Read-Rendered "$TEMP_DIR/rendered/README.txt") -ne 'raw ${.env}' -or
+        (Read-Rendered "$TEMP_DIR/rendered/bin/run.sh") -ne "echo prod") {
+        Write-Error "Directory integration test failed!"
+        exit 1
+    }
+
+    # Test 7: In-place rendering with backup
+    'env=${.env}' | Out-File -FilePath "$TEMP_DIR/inplace.conf" -Encoding UTF8
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/inplace.conf" --in-place --backup-suffix .bak
+
+    if ((Read-Rendered "$TEMP_DIR/inplace.conf") -ne "env=prod" -or
+        (Read-Rendered "$TEMP_DIR/inplace.conf.bak") -ne 'env=${.env}') {
+        Write-Error "In-place integration test failed!"
+        exit 1
+    }
+
+    # Test 8: Multiple inputs with --output and --output-dir
+    'A=${.env}' | Out-File -FilePath "$TEMP_DIR/a.txt.tmpl" -Encoding UTF8
+    'B=${.env}' | Out-File -FilePath "$TEMP_DIR/b.txt.tmpl" -Encoding UTF8
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --output "$TEMP_DIR/joined.txt" `
+        "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl"
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --output-dir "$TEMP_DIR/mapped" --strip-suffix .tmpl `
+        "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl"
+
+    if ((Read-Rendered "$TEMP_DIR/joined.txt") -ne "A=prod`nB=prod" -or
+        (Read-Rendered "$TEMP_DIR/mapped/b.txt") -ne "B=prod") {
+        Write-Error "Multiple inputs integration test failed!"
+        exit 1
+    }
+
+    # Test 9: Check mode detects drift
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --output "$TEMP_DIR/joined.txt" --check `
+        "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl" | Out-Null
+    if ($LASTEXITCODE -ne 0) {
+        Write-Error "Check mode integration test failed: up-to-date output reported as drift!"
+        exit 1
+    }
+
+    'B=stale' | Out-File -FilePath "$TEMP_DIR/mapped/b.txt" -Encoding UTF8
+    $DIFF_OUTPUT = (& "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --output-dir "$TEMP_DIR/mapped" `
+        --strip-suffix .tmpl --check "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl" 2>$null) -join "`n"
+    if ($LASTEXITCODE -eq 0) {
+        Write-Error "Check mode integration test failed: drift not detected!"
+        exit 1
+    }
+    if (-not $DIFF_OUTPUT.Contains("+B=prod") -or (Read-Rendered "$TEMP_DIR/mapped/b.txt") -ne "B=stale") {
+        Write-Error "Check mode integration test failed!`nGot: $DIFF_OUTPUT"
+        exit 1
+    }
+
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" `
+        --foreach .services --output "$TEMP_DIR/out/all.conf" --check | Out-Null
+    if ($LASTEXITCODE -ne 0) {
+        Write-Error "Check mode integration test failed: up-to-date --foreach output reported as drift!"
+        exit 1
+    }
+
+    New-Item -ItemType Directory -Path "$TEMP_DIR/empty" | Out-Null
+    & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/services.yaml" --input-dir "$TEMP_DIR/empty" `
+        --output-dir "$TEMP_DIR/empty-out" --check 2>$null
+    if ($LASTEXITCODE -eq 0) {
+        Write-Error "Check mode integration test failed: passed without comparing any file!"
+        exit 1
+    }
+
+    # Test 10: Watch mode re-renders on change
+    'env: prod' | Out-File -FilePath "$TEMP_DIR/watch.yaml" -Encoding UTF8
+    'env=${.env}' | Out-File -FilePath "$TEMP_DIR/watch.tmpl" -Encoding UTF8
+
+    $WATCH = Start-Process -FilePath "$TEMP_DIR/yamlsubst.exe" -NoNewWindow -PassThru `
+        -RedirectStandardError "$TEMP_DIR/watch.log" -ArgumentList @(
+            "--yaml", "$TEMP_DIR/watch.yaml", "--file", "$TEMP_DIR/watch.tmpl",
+            "--output", "$TEMP_DIR/watch.out", "--watch", "--watch-interval", "100ms")
+    Start-Sleep -Seconds 1
+    'env: dev' | Out-File -FilePath "$TEMP_DIR/watch.yaml" -Encoding UTF8
+    Start-Sleep -Seconds 1
+    Stop-Process -Id $WATCH.Id
+    $WATCH.WaitForExit()
+
+    if ((Read-Rendered "$TEMP_DIR/watch.out") -ne "env=dev" -or
+        -not (Select-String -Path "$TEMP_DIR/watch.log" -SimpleMatch 'prod -> dev' -Quiet)) {
+        Write-Error "Watch mode integration test failed!`n$(Get-Content -Raw "$TEMP_DIR/watch.log")"
+        exit 1
+    }
+
+    # Test 11: Decimal arithmetic
+    'price: 19.99' | Out-File -FilePath "$TEMP_DIR/price.yaml" -Encoding UTF8
+    $DECIMAL_OUTPUT = ('total=${.price * 1.15}' | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" `
+        --decimal --decimal-scale 2 --rounding half-even) -join "`n"
+    if ($DECIMAL_OUTPUT -ne "total=22.99") {
+        Write-Error "Decimal integration test failed!`nExpected: total=22.99`nGot: $DECIMAL_OUTPUT"
+        exit 1
+    }
+
+    # Test 12: Number formatting
+    $FORMAT_OUTPUT = ('total=${.price * 1.15} mode=${493 :%#o}' | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" `
+        --float-precision 2) -join "`n"
+    if ($FORMAT_OUTPUT -ne "total=22.99 mode=0755") {
+        Write-Error "Number formatting integration test failed!`nExpected: total=22.99 mode=0755`nGot: $FORMAT_OUTPUT"
+        exit 1
+    }
+
+    # Test 13: Pinned clock
+    $TIME_OUTPUT = ('built=${date("%Y-%m-%d")} epoch=${unix()}' | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" `
+        --now 2025-01-01T00:00:00Z) -join "`n"
+    if ($TIME_OUTPUT -ne "built=2025-01-01 epoch=1735689600") {
+        Write-Error "Pinned clock integration test failed!`nExpected: built=2025-01-01 epoch=1735689600`nGot: $TIME_OUTPUT"
+        exit 1
+    }
+
+    # Test 14: Seeded and persisted random values
+    $RANDOM_TEMPLATE = 'password=${random_string(16, "alnum", "db")} again=${random_string(16, "alnum", "db")} other=${random_string(16)} pin=${random_int(1000, 9999)}'
+    $SEEDED_FIRST = ($RANDOM_TEMPLATE | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" --seed 42) -join "`n"
+    $SEEDED_SECOND = ($RANDOM_TEMPLATE | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" --seed 42) -join "`n"
+    $FIELDS = $SEEDED_FIRST -split ' '
+    if ($SEEDED_FIRST -ne $SEEDED_SECOND -or
+        ($FIELDS[0] -replace '^password=') -ne ($FIELDS[1] -replace '^again=') -or
+        ($FIELDS[0] -replace '^password=') -eq ($FIELDS[2] -replace '^other=')) {
+        Write-Error "Seeded random values integration test failed!`nFirst: $SEEDED_FIRST`nSecond: $SEEDED_SECOND"
+        exit 1
+    }
+    $PERSISTED_FIRST = ($RANDOM_TEMPLATE | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" `
+        --persist-generated "$TEMP_DIR/generated.yaml") -join "`n"
+    $PERSISTED_SECOND = ($RANDOM_TEMPLATE | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" `
+        --persist-generated "$TEMP_DIR/generated.yaml") -join "`n"
+    if ($PERSISTED_FIRST -ne $PERSISTED_SECOND -or
+        -not (Select-String -Path "$TEMP_DIR/generated.yaml" -SimpleMatch 'random_string(16)#1' -Quiet)) {
+        Write-Error "Persisted random values integration test failed!`nFirst: $PERSISTED_FIRST`nSecond: $PERSISTED_SECOND"
+        exit 1
+    }
+
+    # Test 15: Invalid placeholders are kept and reported
+    $INVALID_OUTPUT = ('total=${.price | shout}' | & "$TEMP_DIR/yamlsubst.exe" --yaml "$TEMP_DIR/price.yaml" 2>"$TEMP_DIR/warnings.txt") -join "`n"
+    $WARNINGS = Get-Content "$TEMP_DIR/warnings.txt" -Raw
+    if ($INVALID_OUTPUT -ne 'total=${.price | shout}' -or $WARNINGS -notmatch 'unknown filter: shout') {
+        Write-Error "Invalid placeholder integration test failed!`nGot: $INVALID_OUTPUT`nWarnings: $WARNINGS"
+        exit 1
+    }
+
     Write-Host ""
     Write-Host "All integration tests passed! ✓" -ForegroundColor Green
 
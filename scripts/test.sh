@@ -78,5 +78,202 @@ if [[ ! "$HELP_OUTPUT" =~ "yamlsubst is a CLI tool" ]]; then
     exit 1
 fi
 
+# Test 5: Foreach rendering
+cat > "$TEMP_DIR/services.yaml" << 'EOF'
+env: prod
+services:
+  - name: api
+    port: 80
+  - name: web
+    port: 8080
+EOF
+
+echo 'name=${.item.name} port=${.item.port} index=${.loop.index} env=${.env}' > "$TEMP_DIR/service.tmpl"
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" \
+    --foreach .services --foreach-output "$TEMP_DIR/out/\${.item.name}.conf"
+RESULT=$(cat "$TEMP_DIR/out/web.conf")
+EXPECTED="name=web port=8080 index=1 env=prod"
+
+if [ "$RESULT" != "$EXPECTED" ]; then
+    echo "Foreach integration test failed!"
+    echo "Expected: $EXPECTED"
+    echo "Got: $RESULT"
+    exit 1
+fi
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" \
+    --foreach .services --output "$TEMP_DIR/out/all.conf"
+RESULT=$(cat "$TEMP_DIR/out/all.conf")
+EXPECTED="name=api port=80 index=0 env=prod
+name=web port=8080 index=1 env=prod"
+
+if [ "$RESULT" != "$EXPECTED" ]; then
+    echo "Foreach with --output integration test failed!"
+    echo "Expected: $EXPECTED"
+    echo "Got: $RESULT"
+    exit 1
+fi
+
+# Test 6: Directory rendering
+mkdir -p "$TEMP_DIR/templates/bin"
+echo 'env=${.env}' > "$TEMP_DIR/templates/app.conf.tmpl"
+echo 'raw ${.env}' > "$TEMP_DIR/templates/README.txt"
+echo 'echo ${.env}' > "$TEMP_DIR/templates/bin/run.sh.tmpl"
+chmod 755 "$TEMP_DIR/templates/bin/run.sh.tmpl"
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --input-dir "$TEMP_DIR/templates" \
+    --output-dir "$TEMP_DIR/rendered" --include '*.tmpl' --strip-suffix .tmpl
+
+if [ "$(cat "$TEMP_DIR/rendered/app.conf")" != "env=prod" ] ||
+   [ "$(cat "$TEMP_DIR/rendered/README.txt")" != 'raw ${.env}' ] ||
+   [ ! -x "$TEMP_DIR/rendered/bin/run.sh" ]; then
+    echo "Directory integration test failed!"
+    ls -lR "$TEMP_DIR/rendered"
+    exit 1
+fi
+
+# Test 7: In-place rendering with backup
+echo 'env=${.env}' > "$TEMP_DIR/inplace.conf"
+chmod 640 "$TEMP_DIR/inplace.conf"
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/inplace.conf" --in-place --backup-suffix .bak
+
+if [ "$(cat "$TEMP_DIR/inplace.conf")" != "env=prod" ] ||
+   [ "$(cat "$TEMP_DIR/inplace.conf.bak")" != 'env=${.env}' ] ||
+   [ "$(stat -c %a "$TEMP_DIR/inplace.conf")" != "640" ]; then
+    echo "In-place integration test failed!"
+    ls -l "$TEMP_DIR"/inplace.conf*
+    exit 1
+fi
+
+# Test 8: Multiple inputs with --output and --output-dir
+echo 'A=${.env}' > "$TEMP_DIR/a.txt.tmpl"
+echo 'B=${.env}' > "$TEMP_DIR/b.txt.tmpl"
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --output "$TEMP_DIR/joined.txt" \
+    "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl"
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --output-dir "$TEMP_DIR/mapped" --strip-suffix .tmpl \
+    "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl"
+
+if [ "$(cat "$TEMP_DIR/joined.txt")" != "A=prod
+B=prod" ] || [ "$(cat "$TEMP_DIR/mapped/b.txt")" != "B=prod" ]; then
+    echo "Multiple inputs integration test failed!"
+    exit 1
+fi
+
+# Test 9: Check mode detects drift
+if ! "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --output "$TEMP_DIR/joined.txt" --check \
+    "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl" > /dev/null; then
+    echo "Check mode integration test failed: up-to-date output reported as drift!"
+    exit 1
+fi
+
+echo 'B=stale' > "$TEMP_DIR/mapped/b.txt"
+if DIFF_OUTPUT=$("$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --output-dir "$TEMP_DIR/mapped" \
+    --strip-suffix .tmpl --check "$TEMP_DIR/a.txt.tmpl" "$TEMP_DIR/b.txt.tmpl" 2>/dev/null); then
+    echo "Check mode integration test failed: drift not detected!"
+    exit 1
+fi
+if [[ ! "$DIFF_OUTPUT" =~ "+B=prod" ]] || [ "$(cat "$TEMP_DIR/mapped/b.txt")" != "B=stale" ]; then
+    echo "Check mode integration test failed!"
+    echo "Got: $DIFF_OUTPUT"
+    exit 1
+fi
+
+if ! "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --file "$TEMP_DIR/service.tmpl" \
+    --foreach .services --output "$TEMP_DIR/out/all.conf" --check > /dev/null; then
+    echo "Check mode integration test failed: up-to-date --foreach output reported as drift!"
+    exit 1
+fi
+
+mkdir -p "$TEMP_DIR/empty"
+if "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/services.yaml" --input-dir "$TEMP_DIR/empty" \
+    --output-dir "$TEMP_DIR/empty-out" --check 2>/dev/null; then
+    echo "Check mode integration test failed: passed without comparing any file!"
+    exit 1
+fi
+
+# Test 10: Watch mode re-renders on change
+printf 'env: prod\n' > "$TEMP_DIR/watch.yaml"
+echo 'env=${.env}' > "$TEMP_DIR/watch.tmpl"
+
+"$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/watch.yaml" --file "$TEMP_DIR/watch.tmpl" \
+    --output "$TEMP_DIR/watch.out" --watch --watch-interval 100ms 2> "$TEMP_DIR/watch.log" &
+WATCH_PID=$!
+sleep 1
+printf 'env: dev\n' > "$TEMP_DIR/watch.yaml"
+sleep 1
+kill -INT $WATCH_PID
+wait $WATCH_PID || true
+
+if [ "$(cat "$TEMP_DIR/watch.out")" != "env=dev" ] || ! grep -q 'prod -> dev' "$TEMP_DIR/watch.log"; then
+    echo "Watch mode integration test failed!"
+    cat "$TEMP_DIR/watch.log"
+    exit 1
+fi
+
+# Test 11: Decimal arithmetic
+printf 'price: 19.99\n' > "$TEMP_DIR/price.yaml"
+DECIMAL_OUTPUT=$(echo 'total=${.price * 1.15}' | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" \
+    --decimal --decimal-scale 2 --rounding half-even)
+if [ "$DECIMAL_OUTPUT" != "total=22.99" ]; then
+    echo "Decimal integration test failed!"
+    echo "Expected: total=22.99"
+    echo "Got: $DECIMAL_OUTPUT"
+    exit 1
+fi
+
+# Test 12: Number formatting
+FORMAT_OUTPUT=$(echo 'total=${.price * 1.15} mode=${493 :%#o}' | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" \
+    --float-precision 2)
+if [ "$FORMAT_OUTPUT" != "total=22.99 mode=0755" ]; then
+    echo "Number formatting integration test failed!"
+    echo "Expected: total=22.99 mode=0755"
+    echo "Got: $FORMAT_OUTPUT"
+    exit 1
+fi
+
+# Test 13: Pinned clock
+TIME_OUTPUT=$(echo 'built=${date("%Y-%m-%d")} epoch=${unix()}' | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" \
+    --now 2025-01-01T00:00:00Z)
+if [ "$TIME_OUTPUT" != "built=2025-01-01 epoch=1735689600" ]; then
+    echo "Pinned clock integration test failed!"
+    echo "Expected: built=2025-01-01 epoch=1735689600"
+    echo "Got: $TIME_OUTPUT"
+    exit 1
+fi
+
+# Test 14: Seeded and persisted random values
+RANDOM_TEMPLATE='password=${random_string(16, "alnum", "db")} again=${random_string(16, "alnum", "db")} other=${random_string(16)} pin=${random_int(1000, 9999)}'
+SEEDED_FIRST=$(echo "$RANDOM_TEMPLATE" | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" --seed 42)
+SEEDED_SECOND=$(echo "$RANDOM_TEMPLATE" | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" --seed 42)
+set -- $SEEDED_FIRST
+if [ "$SEEDED_FIRST" != "$SEEDED_SECOND" ] || [ "${1#password=}" != "${2#again=}" ] || [ "${1#password=}" = "${3#other=}" ]; then
+    echo "Seeded random values integration test failed!"
+    echo "First: $SEEDED_FIRST"
+    echo "Second: $SEEDED_SECOND"
+    exit 1
+fi
+PERSISTED_FIRST=$(echo "$RANDOM_TEMPLATE" | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" \
+    --persist-generated "$TEMP_DIR/generated.yaml")
+PERSISTED_SECOND=$(echo "$RANDOM_TEMPLATE" | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" \
+    --persist-generated "$TEMP_DIR/generated.yaml")
+if [ "$PERSISTED_FIRST" != "$PERSISTED_SECOND" ] || ! grep -q 'random_string(16)#1' "$TEMP_DIR/generated.yaml"; then
+    echo "Persisted random values integration test failed!"
+    echo "First: $PERSISTED_FIRST"
+    echo "Second: $PERSISTED_SECOND"
+    exit 1
+fi
+
+# Test 15: Invalid placeholders are kept and reported
+INVALID_OUTPUT=$(echo 'total=${.price | shout}' | "$TEMP_DIR/yamlsubst" --yaml "$TEMP_DIR/price.yaml" 2>"$TEMP_DIR/warnings.txt")
+if [ "$INVALID_OUTPUT" != 'total=${.price | shout}' ] || ! grep -q 'unknown filter: shout' "$TEMP_DIR/warnings.txt"; then
+    echo "Invalid placeholder integration test failed!"
+    echo "Got: $INVALID_OUTPUT"
+    echo "Warnings: $(cat "$TEMP_DIR/warnings.txt")"
+    exit 1
+fi
+
 echo ""
 echo "All integration tests passed! ✓"